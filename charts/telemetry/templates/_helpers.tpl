@@ -0,0 +1,103 @@
+{{/*
+the name of the ServiceAccount, rbac, ConfigMap and CronJob or Deployment.
+*/}}
+{{- define "telemetry.name" -}}
+{{- default .Chart.Name .Values.nameOverride | trunc 63 | trimSuffix "-" }}
+{{- end }}
+
+{{- define "telemetry.labels" -}}
+app.kubernetes.io/name: {{ include "telemetry.name" . }}
+{{- end }}
+
+{{/*
+whether telemetry keeps running. it's a Deployment when true, otherwise a CronJob.
+the interval is zero when it's empty or a duration of zeros, e.g. 0, 0s, 0m, 0h0m0s.
+*/}}
+{{- define "telemetry.longRunning" -}}
+{{- if not (regexMatch `^[-+]?(0|((0+(\.0*)?|\.0+)(ns|us|µs|μs|ms|s|m|h))*)$` (toString .Values.config.interval)) }}true{{ end }}
+{{- end }}
+
+{{/*
+whether replicas elect a leader in long-running mode.
+*/}}
+{{- define "telemetry.leaderElect" -}}
+{{- if and (include "telemetry.longRunning" .) (gt (int .Values.replicas) 1) }}true{{ end }}
+{{- end }}
+
+{{/*
+the rbac rules derived from the resources which telemetry, collectors and the cloud report access.
+files/rules.yaml is generated by `go test ./pkg/telemetry/manifests -update`.
+*/}}
+{{- define "telemetry.rules" -}}
+{{- $rules := .Files.Get "files/rules.yaml" | fromYaml }}
+{{- $clusterRules := concat $rules.telemetry.clusterRules $rules.collectors.clusterRules }}
+{{- $namespaceRules := deepCopy (default dict $rules.collectors.namespaceRules) }}
+{{- if .Values.config.url }}
+{{- $clusterRules = concat $clusterRules $rules.cloud.clusterRules }}
+{{- if .Values.config.cloudSecret }}
+{{- $secret := splitList "/" .Values.config.cloudSecret }}
+{{- $namespace := ternary (first $secret) "kubesphere-system" (eq (len $secret) 2) }}
+{{- $rule := dict "apiGroups" (list "") "resources" (list "secrets") "verbs" (list "get") "resourceNames" (list (last $secret)) }}
+{{- $_ := set $namespaceRules $namespace (append (default list (get $namespaceRules $namespace)) $rule) }}
+{{- end }}
+{{- end }}
+{{- $rule := dict "apiGroups" (list "") "resources" (list "configmaps") "verbs" (list "get") "resourceNames" (list (include "telemetry.name" .)) }}
+{{- $_ := set $namespaceRules .Release.Namespace (append (default list (get $namespaceRules .Release.Namespace)) $rule) }}
+{{- if include "telemetry.leaderElect" . }}
+{{- $rule := dict "apiGroups" (list "coordination.k8s.io") "resources" (list "leases") "verbs" (list "create" "get" "update") }}
+{{- $_ := set $namespaceRules .Release.Namespace (append (get $namespaceRules .Release.Namespace) $rule) }}
+{{- end }}
+{{- dict "clusterRules" $clusterRules "namespaceRules" $namespaceRules | toYaml }}
+{{- end }}
+
+{{- define "telemetry.podTemplate" -}}
+metadata:
+  labels:
+    {{- include "telemetry.labels" . | nindent 4 }}
+spec:
+  serviceAccountName: {{ include "telemetry.name" . }}
+  {{- if not (include "telemetry.longRunning" .) }}
+  restartPolicy: OnFailure
+  {{- end }}
+  containers:
+  - name: telemetry
+    image: "{{ .Values.image.repository }}:{{ .Values.image.tag | default .Chart.AppVersion }}"
+    imagePullPolicy: {{ .Values.image.pullPolicy }}
+    args:
+    - --config-map
+    - {{ .Release.Namespace }}/{{ include "telemetry.name" . }}
+    {{- if include "telemetry.leaderElect" . }}
+    - --leader-elect
+    - --leader-election-name
+    - {{ include "telemetry.name" . }}
+    {{- end }}
+    # the events of a run are recorded on the pod
+    env:
+    - name: POD_NAME
+      valueFrom:
+        fieldRef:
+          fieldPath: metadata.name
+    - name: POD_NAMESPACE
+      valueFrom:
+        fieldRef:
+          fieldPath: metadata.namespace
+    resources:
+      {{- toYaml .Values.resources | nindent 6 }}
+    securityContext:
+      allowPrivilegeEscalation: false
+      capabilities:
+        drop:
+        - ALL
+  {{- with .Values.nodeSelector }}
+  nodeSelector:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  {{- with .Values.tolerations }}
+  tolerations:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  {{- with .Values.affinity }}
+  affinity:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+{{- end }}
@@ -17,40 +17,259 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
 
 	"kubesphere.io/telemetry/pkg/telemetry"
+	"kubesphere.io/telemetry/pkg/telemetry/collector"
+	telemetryconfig "kubesphere.io/telemetry/pkg/telemetry/config"
 	"kubesphere.io/telemetry/pkg/telemetry/report"
 )
 
 const (
-	ENV_HISTORY_RETENTION   = "TELEMETRY_HISTORY_RETENTION"
-	defaultHistoryRetention = 365 * 24 * time.Hour
+	// envPodName and envPodNamespace are set by the downward api. the events of a run are recorded on the pod.
+	envPodName      = "POD_NAME"
+	envPodNamespace = "POD_NAMESPACE"
 )
 
 type telemetryOptions struct {
-	url     string
-	cloudID string
-	// clusterInfo live time. valid when product is kse.
-	historyRetention time.Duration
+	// configFile the path of the configuration file.
+	configFile string
+	// configMap the namespace/name of the ConfigMap which stores the configuration file.
+	configMap string
+	telemetryconfig.Config
+	// flags which override the configuration file and env.
+	flags *pflag.FlagSet
+	// ignoreEnv the configuration is not loaded from env. e.g. the manifests are rendered for another environment.
+	ignoreEnv bool
+	// leaderElection is only used in long-running mode.
+	leaderElection *leaderElectionOptions
 }
 
 func defaultTelemetryOptions() *telemetryOptions {
-	// get history retension from env
-	hr, err := time.ParseDuration(os.Getenv(ENV_HISTORY_RETENTION))
-	if err != nil || hr == 0 {
-		hr = defaultHistoryRetention
-	}
 	return &telemetryOptions{
-		historyRetention: hr,
+		Config:         *telemetryconfig.New(),
+		leaderElection: defaultLeaderElectionOptions(),
+	}
+}
+
+func (o *telemetryOptions) addFlags(fs *pflag.FlagSet) {
+	o.addConfigFlags(fs)
+	o.leaderElection.addFlags(fs)
+}
+
+// addConfigFlags adds the flags which load or override the configuration.
+func (o *telemetryOptions) addConfigFlags(fs *pflag.FlagSet) {
+	o.flags = pflag.NewFlagSet("telemetry", pflag.ContinueOnError)
+	o.flags.StringVar(&o.URL, "url", o.URL, "the url for kubesphere cloud")
+	o.flags.StringVar(&o.CloudID, "cloud-id", o.CloudID, "the id for kubesphere cloud")
+	o.flags.StringVar(&o.CloudSecret, "cloud-secret", o.CloudSecret, "the namespace/name of the secret which stores cloudId, token and client certificates for kubesphere cloud")
+	o.flags.DurationVar(&o.HistoryRetention.Duration, "history-retention", o.HistoryRetention.Duration, "how long the clusterInfo crd retention. ")
+	o.flags.IntVar(&o.HistoryMaxCount, "history-max-count", o.HistoryMaxCount, "the max number of clusterInfo crd. the oldest are pruned first. zero means no limit")
+	o.flags.Var(quantityValue{&o.HistoryMaxSize}, "history-max-size", "the max total size of the status of clusterInfo crd stored in etcd, e.g. 100Mi. zero means no limit")
+	o.flags.BoolVar(&o.HistoryPruneUnsynced, "history-prune-unsynced", o.HistoryPruneUnsynced, "whether the clusterInfo crd which is not synced to kubesphere cloud can be pruned by the retention")
+	o.flags.BoolVar(&o.HistoryCompaction.Enabled, "history-compaction", o.HistoryCompaction.Enabled, "down-sample the old clusterInfo crd")
+	o.flags.DurationVar(&o.HistoryCompaction.KeepAll.Duration, "history-compaction-keep-all", o.HistoryCompaction.KeepAll.Duration, "keep every clusterInfo crd collected in this duration")
+	o.flags.DurationVar(&o.HistoryCompaction.Daily.Duration, "history-compaction-daily", o.HistoryCompaction.Daily.Duration, "keep the first and the last clusterInfo crd of each day collected in this duration")
+	o.flags.DurationVar(&o.HistoryCompaction.Weekly.Duration, "history-compaction-weekly", o.HistoryCompaction.Weekly.Duration, "keep the first and the last clusterInfo crd of each week collected in this duration. each month after")
+	o.flags.DurationVar(&o.Interval.Duration, "interval", o.Interval.Duration, "the interval between two collections. run once and exit when it's zero")
+	fs.AddFlagSet(o.flags)
+	fs.StringVar(&o.configFile, "config", o.configFile, "the path of the configuration file")
+	fs.StringVar(&o.configMap, "config-map", o.configMap, "the namespace/name of the configmap which stores the configuration file in key "+telemetryconfig.ConfigMapKey)
+}
+
+// complete loads the configuration with precedence flags > env > file > defaults.
+// it's called before each collection, so that changes of the file or configmap are reloaded in long-running mode.
+func (o *telemetryOptions) complete(ctx context.Context, restConfig *rest.Config) (*telemetryconfig.Config, error) {
+	changed := make(map[*pflag.Flag]string)
+	o.flags.VisitAll(func(f *pflag.Flag) {
+		if f.Changed {
+			changed[f] = f.Value.String()
+		}
+	})
+
+	o.Config = *telemetryconfig.New()
+	switch {
+	case o.configFile != "" && o.configMap != "":
+		return nil, fmt.Errorf("--config and --config-map are mutually exclusive")
+	case o.configFile != "":
+		if err := o.Config.LoadFile(o.configFile); err != nil {
+			return nil, err
+		}
+	case o.configMap != "":
+		ref := telemetryconfig.ParseNamespacedName(o.configMap)
+		kubeClient, err := kubernetes.NewForConfig(restConfig)
+		if err != nil {
+			return nil, err
+		}
+		if err := o.Config.LoadConfigMap(ctx, kubeClient, ref.Namespace, ref.Name); err != nil {
+			return nil, err
+		}
+	}
+	if !o.ignoreEnv {
+		if err := o.Config.LoadEnv(); err != nil {
+			return nil, err
+		}
+	}
+	for f, v := range changed {
+		if err := f.Value.Set(v); err != nil {
+			return nil, fmt.Errorf("invalid flag --%s: %w", f.Name, err)
+		}
+	}
+	if err := o.Config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	cfg := o.Config
+	return &cfg, nil
+}
+
+func (o *telemetryOptions) run(ctx context.Context, restConfig *rest.Config) error {
+	if err := o.leaderElection.validate(); err != nil {
+		return err
+	}
+	cfg, err := o.complete(ctx, restConfig)
+	if err != nil {
+		return err
+	}
+	if cfg.Interval.Duration == 0 && o.leaderElection.leaderElect {
+		return fmt.Errorf("--leader-elect is only supported in long-running mode. set --interval")
+	}
+	broadcaster, recorder, err := newEventRecorder(restConfig)
+	if err != nil {
+		return err
+	}
+	defer broadcaster.Shutdown()
+	if cfg.Interval.Duration == 0 {
+		return runTelemetry(ctx, cfg, restConfig, recorder)
+	}
+	// long-running mode
+	return o.leaderElection.run(ctx, restConfig, func(ctx context.Context) error {
+		return o.loop(ctx, cfg, restConfig, recorder)
+	})
+}
+
+// loop runs telemetry each interval until ctx is done.
+func (o *telemetryOptions) loop(ctx context.Context, cfg *telemetryconfig.Config, restConfig *rest.Config, recorder record.EventRecorder) error {
+	for {
+		if err := runTelemetry(ctx, cfg, restConfig, recorder); err != nil {
+			klog.Errorf("telemetry run error %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(cfg.Interval.Duration):
+		}
+		cfg = o.reload(ctx, restConfig, cfg)
+	}
+}
+
+// reload loads the configuration again in long-running mode. previous is kept when the configuration is invalid,
+// or its interval is changed to zero, which would run telemetry again without delay.
+func (o *telemetryOptions) reload(ctx context.Context, restConfig *rest.Config, previous *telemetryconfig.Config) *telemetryconfig.Config {
+	cfg, err := o.complete(ctx, restConfig)
+	if err != nil {
+		klog.Errorf("reload configuration error %v. keep the previous configuration", err)
+		return previous
+	}
+	if cfg.Interval.Duration == 0 {
+		klog.Errorf("interval can't be changed to zero in long-running mode. keep the previous configuration")
+		return previous
+	}
+	return cfg
+}
+
+// newEventRecorder returns a recorder which records events of telemetry to kube-apiserver.
+// the events are sent asynchronously, so that the events still pending when the broadcaster is shutdown may be lost.
+// the broadcaster should be shutdown to stop the recording before exit.
+func newEventRecorder(restConfig *rest.Config) (record.EventBroadcaster, record.EventRecorder, error) {
+	kubeClient, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
+	return broadcaster, broadcaster.NewRecorder(collector.Schema, corev1.EventSource{Component: "telemetry"}), nil
+}
+
+// podReference returns the pod which runs telemetry. it's nil when telemetry doesn't run in a pod.
+func podReference() runtime.Object {
+	name, namespace := os.Getenv(envPodName), os.Getenv(envPodNamespace)
+	if name == "" || namespace == "" {
+		return nil
+	}
+	return &corev1.ObjectReference{APIVersion: "v1", Kind: "Pod", Name: name, Namespace: namespace}
+}
+
+func runTelemetry(ctx context.Context, cfg *telemetryconfig.Config, restConfig *rest.Config, recorder record.EventRecorder) error {
+	pod := podReference()
+	// set report
+	var reporter report.Report
+	if cfg.URL == "" {
+		reporter = report.NewLocalReport()
+	} else { // sync to cloud
+		opts := []report.CloudOption{
+			report.WithEventRecorder(recorder, pod),
+			report.WithHistoryLimits(cfg.HistoryMaxCount, cfg.HistoryMaxSize.Value()),
+			report.WithPruneUnsynced(cfg.HistoryPruneUnsynced),
+		}
+		if cfg.HistoryCompaction.Enabled {
+			opts = append(opts, report.WithCompaction(report.CompactionPolicy{
+				KeepAll: cfg.HistoryCompaction.KeepAll.Duration,
+				Daily:   cfg.HistoryCompaction.Daily.Duration,
+				Weekly:  cfg.HistoryCompaction.Weekly.Duration,
+			}))
+		}
+		if cfg.CloudSecret != "" {
+			opts = append(opts, report.WithCredentialsSecret(telemetryconfig.ParseNamespacedName(cfg.CloudSecret)))
+		}
+		rt, err := report.NewCloudReport(cfg.URL, cfg.CloudID, cfg.HistoryRetention.Duration, restConfig, opts...)
+		if err != nil {
+			return err
+		}
+		reporter = rt
 	}
+	return telemetry.NewTelemetry(telemetry.WithConfig(restConfig), telemetry.WithReport(reporter), telemetry.WithEventRecorder(recorder, pod)).Start(ctx)
+}
+
+// quantityValue binds a flag to resource.Quantity.
+type quantityValue struct {
+	q *resource.Quantity
+}
+
+func (v quantityValue) String() string {
+	if v.q == nil {
+		return ""
+	}
+	return v.q.String()
+}
+
+func (v quantityValue) Set(s string) error {
+	q, err := resource.ParseQuantity(s)
+	if err != nil {
+		return err
+	}
+	*v.q = q
+	return nil
+}
+
+func (v quantityValue) Type() string {
+	return "quantity"
 }
 
 func NewTelemetryCommand(version string) *cobra.Command {
@@ -61,26 +280,16 @@ func NewTelemetryCommand(version string) *cobra.Command {
 		Long:    "telemetry cluster-info and send to cloud",
 		Version: version,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// get cli
-			// set report
-			var reporter report.Report
-			if o.url == "" {
-				reporter = report.NewLocalReport()
-			} else { // sync to cloud
-				rt, err := report.NewCloudReport(o.url, o.cloudID, o.historyRetention, config.GetConfigOrDie())
-				if err != nil {
-					return err
-				}
-				reporter = rt
-			}
-			return telemetry.NewTelemetry(telemetry.WithConfig(config.GetConfigOrDie()), telemetry.WithReport(reporter)).Start(signals.SetupSignalHandler())
+			return o.run(signals.SetupSignalHandler(), config.GetConfigOrDie())
 		},
 	}
 	cmd.Flags().AddGoFlagSet(flag.CommandLine)
-	cmd.Flags().StringVar(&o.url, "url", o.url, "the url for kubesphere cloud")
-	cmd.Flags().StringVar(&o.cloudID, "cloud-id", o.cloudID, "the id for kubesphere cloud")
-	cmd.Flags().DurationVar(&o.historyRetention, "history-retention", o.historyRetention, "how long the clusterInfo crd retention. ")
+	o.addFlags(cmd.Flags())
 	cmd.AddCommand(versionCmd(version))
+	cmd.AddCommand(diffCmd())
+	cmd.AddCommand(historyCmd())
+	cmd.AddCommand(manifestsCmd(version))
+	cmd.AddCommand(preflightCmd())
 	return cmd
 }
 
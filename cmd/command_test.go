@@ -0,0 +1,132 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	telemetryconfig "kubesphere.io/telemetry/pkg/telemetry/config"
+)
+
+func TestComplete(t *testing.T) {
+	path := filepath.Join(t.TempDir(), telemetryconfig.ConfigMapKey)
+	if err := os.WriteFile(path, []byte(`apiVersion: telemetry.kubesphere.io/v1alpha1
+kind: TelemetryConfiguration
+url: https://file.kubesphere.cloud
+cloudId: file
+historyMaxCount: 10
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		env       map[string]string
+		ignoreEnv bool
+		args      []string
+		// want url, cloudId and historyMaxCount
+		wantURL, wantCloudID string
+		wantMaxCount         int
+	}{
+		{name: "file", wantURL: "https://file.kubesphere.cloud", wantCloudID: "file", wantMaxCount: 10},
+		{
+			name:    "env overrides file",
+			env:     map[string]string{telemetryconfig.ENV_URL: "https://env.kubesphere.cloud"},
+			wantURL: "https://env.kubesphere.cloud", wantCloudID: "file", wantMaxCount: 10,
+		},
+		{
+			name:      "env ignored",
+			env:       map[string]string{telemetryconfig.ENV_URL: "https://env.kubesphere.cloud"},
+			ignoreEnv: true,
+			wantURL:   "https://file.kubesphere.cloud", wantCloudID: "file", wantMaxCount: 10,
+		},
+		{
+			name:    "flags override env",
+			env:     map[string]string{telemetryconfig.ENV_URL: "https://env.kubesphere.cloud"},
+			args:    []string{"--url", "https://flag.kubesphere.cloud", "--history-max-count", "0"},
+			wantURL: "https://flag.kubesphere.cloud", wantCloudID: "file", wantMaxCount: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			o := defaultTelemetryOptions()
+			o.ignoreEnv = tt.ignoreEnv
+			fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			o.addConfigFlags(fs)
+			if err := fs.Parse(append([]string{"--config", path}, tt.args...)); err != nil {
+				t.Fatal(err)
+			}
+			// complete is called before each collection. the result must be the same.
+			for i := 0; i < 2; i++ {
+				cfg, err := o.complete(context.Background(), nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if cfg.URL != tt.wantURL || cfg.CloudID != tt.wantCloudID || cfg.HistoryMaxCount != tt.wantMaxCount {
+					t.Errorf("url = %s, cloudId = %s, historyMaxCount = %d, want %s, %s, %d",
+						cfg.URL, cfg.CloudID, cfg.HistoryMaxCount, tt.wantURL, tt.wantCloudID, tt.wantMaxCount)
+				}
+			}
+		})
+	}
+}
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), telemetryconfig.ConfigMapKey)
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte("apiVersion: telemetry.kubesphere.io/v1alpha1\nkind: TelemetryConfiguration\n"+content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name         string
+		content      string
+		wantInterval time.Duration
+	}{
+		{name: "interval changed", content: "interval: 1h\n", wantInterval: time.Hour},
+		{name: "interval changed to zero", content: "interval: 0s\n", wantInterval: 24 * time.Hour},
+		{name: "interval removed", content: "", wantInterval: 24 * time.Hour},
+		{name: "invalid configuration", content: "interval: -1h\n", wantInterval: 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			write("interval: 24h\n")
+			o := defaultTelemetryOptions()
+			fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			o.addConfigFlags(fs)
+			if err := fs.Parse([]string{"--config", path}); err != nil {
+				t.Fatal(err)
+			}
+			previous, err := o.complete(context.Background(), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			write(tt.content)
+			if got := o.reload(context.Background(), nil, previous); got.Interval.Duration != tt.wantInterval {
+				t.Errorf("interval = %s, want %s", got.Interval.Duration, tt.wantInterval)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/telemetry/pkg/telemetry/report"
+	"kubesphere.io/telemetry/pkg/telemetry/snapshot"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+type diffOptions struct {
+	output string
+	// client is created when a snapshot is a ClusterInfo
+	client runtimeclient.Client
+}
+
+func diffCmd() *cobra.Command {
+	o := &diffOptions{output: outputText}
+	cmd := &cobra.Command{
+		Use:   "diff <clusterinfo-a> <clusterinfo-b>",
+		Short: "Display the changes between two snapshots",
+		Long:  "Display the changes between two snapshots. a snapshot is the name of a ClusterInfo or the path of a local report file.",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if o.output != outputText && o.output != outputJSON {
+				return fmt.Errorf("unsupported output format %s", o.output)
+			}
+			a, err := o.load(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			b, err := o.load(cmd.Context(), args[1])
+			if err != nil {
+				return err
+			}
+			d := snapshot.ComputeDiff(a, b)
+			if o.output == outputJSON {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(d)
+			}
+			printDiff(cmd.OutOrStdout(), d)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&o.output, "output", "o", o.output, "output format. one of text, json")
+	return cmd
+}
+
+// load reads the snapshot from local report file when it exists, otherwise from ClusterInfo.
+func (o *diffOptions) load(ctx context.Context, name string) (*snapshot.Snapshot, error) {
+	var data map[string]any
+	if _, err := os.Stat(name); err == nil {
+		if data, err = report.ReadLocalReport(name); err != nil {
+			return nil, err
+		}
+	} else {
+		if o.client == nil {
+			if o.client, err = newRuntimeClient(); err != nil {
+				return nil, err
+			}
+		}
+		if data, err = report.GetClusterInfoData(ctx, o.client, name); err != nil {
+			return nil, err
+		}
+	}
+	return snapshot.Decode(data)
+}
+
+func printDiff(w io.Writer, d *snapshot.Diff) {
+	fmt.Fprintf(w, "Snapshot %s -> %s\n", d.From, d.To)
+	if len(d.ClustersAdded) != 0 || len(d.ClustersRemoved) != 0 {
+		fmt.Fprintln(w, "\nClusters:")
+		for _, name := range d.ClustersAdded {
+			fmt.Fprintf(w, "  + %s\n", name)
+		}
+		for _, name := range d.ClustersRemoved {
+			fmt.Fprintf(w, "  - %s\n", name)
+		}
+	}
+	for _, cd := range d.Clusters {
+		fmt.Fprintf(w, "\nCluster %s:\n", cd.Name)
+		if cd.KSVersion != nil {
+			fmt.Fprintf(w, "  kubesphere: %s -> %s\n", cd.KSVersion.From, cd.KSVersion.To)
+		}
+		if cd.ClusterVersion != nil {
+			fmt.Fprintf(w, "  kubernetes: %s -> %s\n", cd.ClusterVersion.From, cd.ClusterVersion.To)
+		}
+		for _, name := range cd.NodesAdded {
+			fmt.Fprintf(w, "  + node %s\n", name)
+		}
+		for _, name := range cd.NodesRemoved {
+			fmt.Fprintf(w, "  - node %s\n", name)
+		}
+		printCounts(w, cd.Counts)
+	}
+	ed := d.Extensions
+	if len(ed.Added) != 0 || len(ed.Removed) != 0 || len(ed.Changed) != 0 {
+		fmt.Fprintln(w, "\nExtensions:")
+		for _, ext := range ed.Added {
+			fmt.Fprintf(w, "  + %s %s\n", ext.Name, ext.Version.To)
+		}
+		for _, ext := range ed.Removed {
+			fmt.Fprintf(w, "  - %s %s\n", ext.Name, ext.Version.From)
+		}
+		for _, ext := range ed.Changed {
+			var changes []string
+			if ext.Version != nil {
+				changes = append(changes, fmt.Sprintf("version %s -> %s", ext.Version.From, ext.Version.To))
+			}
+			if ext.State != nil {
+				changes = append(changes, fmt.Sprintf("state %s -> %s", ext.State.From, ext.State.To))
+			}
+			fmt.Fprintf(w, "  ~ %s %s\n", ext.Name, strings.Join(changes, ", "))
+		}
+	}
+	if len(d.Counts) != 0 {
+		fmt.Fprintln(w, "\nPlatform:")
+		printCounts(w, d.Counts)
+	}
+}
+
+func printCounts(w io.Writer, counts []snapshot.CountDelta) {
+	for _, c := range counts {
+		fmt.Fprintf(w, "  %s: %d -> %d (%+d)\n", c.Name, c.From, c.To, c.Delta)
+	}
+}
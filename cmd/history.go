@@ -0,0 +1,410 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/duration"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+	"sigs.k8s.io/yaml"
+
+	telemetryv1alpha1 "kubesphere.io/telemetry/pkg/apis/telemetry/v1alpha1"
+	"kubesphere.io/telemetry/pkg/telemetry/collector"
+	telemetryconfig "kubesphere.io/telemetry/pkg/telemetry/config"
+	"kubesphere.io/telemetry/pkg/telemetry/report"
+	"kubesphere.io/telemetry/pkg/telemetry/snapshot"
+)
+
+const (
+	outputTable = "table"
+	outputYAML  = "yaml"
+)
+
+// historyFilter selects ClusterInfo by the time of collection and the sync status.
+type historyFilter struct {
+	since    string
+	until    string
+	synced   bool
+	unsynced bool
+}
+
+func (f *historyFilter) addFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&f.since, "since", f.since, "only snapshots collected after this time. a RFC3339 time or a duration relative to now, e.g. 24h")
+	fs.StringVar(&f.until, "until", f.until, "only snapshots collected before this time. a RFC3339 time or a duration relative to now, e.g. 24h")
+	fs.BoolVar(&f.synced, "synced", f.synced, "only snapshots which are synced to kubesphere cloud")
+	fs.BoolVar(&f.unsynced, "unsynced", f.unsynced, "only snapshots which are not synced to kubesphere cloud")
+}
+
+// match returns a function which reports whether the clusterInfo is selected by f.
+func (f *historyFilter) match(now time.Time) (func(clusterInfo *telemetryv1alpha1.ClusterInfo) bool, error) {
+	if f.synced && f.unsynced {
+		return nil, fmt.Errorf("--synced and --unsynced are mutually exclusive")
+	}
+	since, err := parseTime(f.since, now)
+	if err != nil {
+		return nil, fmt.Errorf("invalid flag --since: %w", err)
+	}
+	until, err := parseTime(f.until, now)
+	if err != nil {
+		return nil, fmt.Errorf("invalid flag --until: %w", err)
+	}
+	return func(clusterInfo *telemetryv1alpha1.ClusterInfo) bool {
+		ts := report.ClusterInfoTime(clusterInfo)
+		if !since.IsZero() && ts.Before(since) {
+			return false
+		}
+		if !until.IsZero() && ts.After(until) {
+			return false
+		}
+		synced := clusterInfo.Status.SyncTime != nil
+		return !(f.synced && !synced) && !(f.unsynced && synced)
+	}, nil
+}
+
+// parseTime parses s as a RFC3339 time or a duration before now. it's zero when s is empty.
+func parseTime(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func historyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Manage the snapshots stored in ClusterInfo",
+	}
+	cmd.AddCommand(historyListCmd(), historyShowCmd(), historyPruneCmd(), historyCompactCmd())
+	return cmd
+}
+
+func historyListCmd() *cobra.Command {
+	f := &historyFilter{}
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the snapshots with sync status and size",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			now := time.Now()
+			match, err := f.match(now)
+			if err != nil {
+				return err
+			}
+			client, err := newRuntimeClient()
+			if err != nil {
+				return err
+			}
+			clusterInfos, err := report.ListClusterInfos(cmd.Context(), client)
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
+			fmt.Fprintln(w, "NAME\tCOLLECTED\tSYNCED\tCLUSTERS\tSIZE\tAGE")
+			for i := range clusterInfos {
+				clusterInfo := &clusterInfos[i]
+				if !match(clusterInfo) {
+					continue
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n",
+					clusterInfo.Name,
+					report.ClusterInfoTime(clusterInfo).UTC().Format(time.RFC3339),
+					syncTime(clusterInfo),
+					len(clusterInfo.Status.Clusters),
+					formatSize(report.StatusSize(clusterInfo)),
+					duration.HumanDuration(now.Sub(clusterInfo.CreationTimestamp.Time)),
+				)
+			}
+			return w.Flush()
+		},
+	}
+	f.addFlags(cmd.Flags())
+	return cmd
+}
+
+func historyShowCmd() *cobra.Command {
+	output := outputTable
+	cmd := &cobra.Command{
+		Use:   "show <clusterinfo>",
+		Short: "Display a snapshot",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != outputTable && output != outputYAML && output != outputJSON {
+				return fmt.Errorf("unsupported output format %s", output)
+			}
+			client, err := newRuntimeClient()
+			if err != nil {
+				return err
+			}
+			clusterInfo := &telemetryv1alpha1.ClusterInfo{}
+			if err := client.Get(cmd.Context(), types.NamespacedName{Name: args[0]}, clusterInfo); err != nil {
+				return err
+			}
+			clusterInfo.SetGroupVersionKind(telemetryv1alpha1.SchemeGroupVersion.WithKind(telemetryv1alpha1.ResourceKindClusterInfo))
+			clusterInfo.ManagedFields = nil
+			switch output {
+			case outputYAML:
+				data, err := yaml.Marshal(clusterInfo)
+				if err != nil {
+					return err
+				}
+				_, err = cmd.OutOrStdout().Write(data)
+				return err
+			case outputJSON:
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(clusterInfo)
+			}
+			return printSnapshot(cmd.OutOrStdout(), clusterInfo)
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", output, "output format. one of table, yaml, json")
+	return cmd
+}
+
+func historyPruneCmd() *cobra.Command {
+	f := &historyFilter{}
+	var policy report.RetentionPolicy
+	maxSize := resource.Quantity{}
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete the snapshots exceeding the retention",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			policy.MaxSize = maxSize.Value()
+			if policy.MaxAge < 0 || policy.MaxCount < 0 || policy.MaxSize < 0 {
+				return fmt.Errorf("--retention, --max-count and --max-size must not be negative")
+			}
+			if policy.MaxAge == 0 && policy.MaxCount == 0 && policy.MaxSize == 0 {
+				return fmt.Errorf("at least one of --retention, --max-count and --max-size is required")
+			}
+			now := time.Now()
+			match, err := f.match(now)
+			if err != nil {
+				return err
+			}
+			client, err := newRuntimeClient()
+			if err != nil {
+				return err
+			}
+			clusterInfos, err := report.ListClusterInfos(cmd.Context(), client)
+			if err != nil {
+				return err
+			}
+			summary, err := pruneHistory(cmd.Context(), client, cmd.OutOrStdout(), policy, clusterInfos, match, now, dryRun)
+			fmt.Fprintln(cmd.OutOrStdout(), summary)
+			return err
+		},
+	}
+	f.addFlags(cmd.Flags())
+	cmd.Flags().DurationVar(&policy.MaxAge, "retention", policy.MaxAge, "delete the snapshots created longer ago than the retention")
+	cmd.Flags().IntVar(&policy.MaxCount, "max-count", policy.MaxCount, "delete the oldest snapshots when there are more snapshots than the max count")
+	cmd.Flags().Var(quantityValue{&maxSize}, "max-size", "delete the oldest snapshots when their total size exceeds the max size, e.g. 100Mi")
+	cmd.Flags().BoolVar(&policy.PruneUnsynced, "prune-unsynced", policy.PruneUnsynced, "also delete the snapshots which are not synced to kubesphere cloud")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", dryRun, "only print the snapshots which would be deleted")
+	return cmd
+}
+
+// pruneHistory deletes the ClusterInfo selected by match which exceed the policy. the policy only applies to
+// the selected ClusterInfo, so the others neither are deleted nor count for the limits.
+func pruneHistory(ctx context.Context, client runtimeclient.Client, w io.Writer, policy report.RetentionPolicy,
+	clusterInfos []telemetryv1alpha1.ClusterInfo, match func(clusterInfo *telemetryv1alpha1.ClusterInfo) bool,
+	now time.Time, dryRun bool) (report.PruneSummary, error) {
+	var selected []telemetryv1alpha1.ClusterInfo
+	for i := range clusterInfos {
+		if match(&clusterInfos[i]) {
+			selected = append(selected, clusterInfos[i])
+		}
+	}
+	summary := policy.Prune(selected, now)
+	var errs error
+	var pruned []report.Pruned
+	for _, p := range summary.Pruned {
+		clusterInfo := p.ClusterInfo
+		if dryRun {
+			fmt.Fprintf(w, "clusterinfo %s deleted by %s (dry run)\n", clusterInfo.Name, p.Reason)
+			pruned = append(pruned, p)
+			continue
+		}
+		if err := client.Delete(ctx, &clusterInfo); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("failed to delete clusterinfo %s: %w", clusterInfo.Name, err))
+			summary.Kept++
+			summary.KeptSize += report.StatusSize(&clusterInfo)
+			continue
+		}
+		fmt.Fprintf(w, "clusterinfo %s deleted by %s\n", clusterInfo.Name, p.Reason)
+		pruned = append(pruned, p)
+	}
+	summary.Pruned = pruned
+	return summary, errs
+}
+
+func historyCompactCmd() *cobra.Command {
+	policy := report.CompactionPolicy{
+		KeepAll: telemetryconfig.DefaultCompactionKeepAll,
+		Daily:   telemetryconfig.DefaultCompactionDaily,
+		Weekly:  telemetryconfig.DefaultCompactionWeekly,
+	}
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   "compact",
+		Short: "Down-sample the old snapshots, keeping the first and the last of each day, week and month",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if policy.KeepAll < 0 || policy.Daily < policy.KeepAll || policy.Weekly < policy.Daily {
+				return fmt.Errorf("--keep-all, --daily and --weekly must be non-negative and in ascending order")
+			}
+			client, err := newRuntimeClient()
+			if err != nil {
+				return err
+			}
+			clusterInfos, err := report.ListClusterInfos(cmd.Context(), client)
+			if err != nil {
+				return err
+			}
+			var errs error
+			var deleted int
+			for _, clusterInfo := range policy.Compact(clusterInfos, time.Now()) {
+				if dryRun {
+					fmt.Fprintf(cmd.OutOrStdout(), "clusterinfo %s deleted (dry run)\n", clusterInfo.Name)
+					deleted++
+					continue
+				}
+				if err := client.Delete(cmd.Context(), &clusterInfo); err != nil {
+					errs = errors.Join(errs, fmt.Errorf("failed to delete clusterinfo %s: %w", clusterInfo.Name, err))
+					continue
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "clusterinfo %s deleted\n", clusterInfo.Name)
+				deleted++
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "compacted %d of %d clusterinfo\n", deleted, len(clusterInfos))
+			return errs
+		},
+	}
+	cmd.Flags().DurationVar(&policy.KeepAll, "keep-all", policy.KeepAll, "keep every snapshot collected in this duration")
+	cmd.Flags().DurationVar(&policy.Daily, "daily", policy.Daily, "keep the first and the last snapshot of each day collected in this duration")
+	cmd.Flags().DurationVar(&policy.Weekly, "weekly", policy.Weekly, "keep the first and the last snapshot of each week collected in this duration. each month after")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", dryRun, "only print the snapshots which would be deleted")
+	return cmd
+}
+
+func newRuntimeClient() (runtimeclient.Client, error) {
+	restConfig, err := config.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	return runtimeclient.New(restConfig, runtimeclient.Options{Scheme: collector.Schema})
+}
+
+func syncTime(clusterInfo *telemetryv1alpha1.ClusterInfo) string {
+	switch {
+	case clusterInfo.Status.SyncTime != nil:
+		return clusterInfo.Status.SyncTime.UTC().Format(time.RFC3339)
+	case report.IsSyncPermanentlyFailed(clusterInfo):
+		return "failed"
+	}
+	return "-"
+}
+
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+	div, exp := unit, 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+func printSnapshot(w io.Writer, clusterInfo *telemetryv1alpha1.ClusterInfo) error {
+	s, err := snapshot.Decode(clusterInfo.Status)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Name:\t%s\n", clusterInfo.Name)
+	fmt.Fprintf(w, "Collected:\t%s\n", report.ClusterInfoTime(clusterInfo).UTC().Format(time.RFC3339))
+	fmt.Fprintf(w, "Synced:\t%s\n", syncTime(clusterInfo))
+	fmt.Fprintf(w, "Size:\t%s\n", formatSize(report.StatusSize(clusterInfo)))
+	fmt.Fprintf(w, "Run ID:\t%s\n", orNone(clusterInfo.Labels[telemetryv1alpha1.LabelRunID]))
+	fmt.Fprintf(w, "Collectors:\t%s\n", orNone(clusterInfo.Annotations[telemetryv1alpha1.AnnotationCollectors]))
+	fmt.Fprintf(w, "Sync Attempts:\t%d\n", clusterInfo.Status.SyncAttempts)
+	if clusterInfo.Status.LastSyncError != "" {
+		fmt.Fprintf(w, "Last Sync Error:\t%s\n", clusterInfo.Status.LastSyncError)
+	}
+	if clusterInfo.Status.NextSyncTime != nil {
+		fmt.Fprintf(w, "Next Sync:\t%s\n", clusterInfo.Status.NextSyncTime.UTC().Format(time.RFC3339))
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	if len(clusterInfo.Status.Conditions) != 0 {
+		fmt.Fprintln(tw, "\nCONDITION\tSTATUS\tREASON\tMESSAGE")
+		for _, c := range clusterInfo.Status.Conditions {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", c.Type, c.Status, c.Reason, c.Message)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(tw, "\nCLUSTER\tROLE\tKUBESPHERE\tKUBERNETES\tNODES")
+	for _, c := range s.Clusters {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\n", c.Name, c.Role, orNone(snapshot.GitVersion(c.KSVersion)), orNone(snapshot.GitVersion(c.ClusterVersion)), len(c.Nodes))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if len(s.Extension) != 0 {
+		fmt.Fprintln(tw, "\nEXTENSION\tVERSION\tSTATE")
+		for _, ext := range s.Extension {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", ext.Name, orNone(ext.Version), orNone(ext.State))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(tw, "\nPLATFORM\tCOUNT")
+	fmt.Fprintf(tw, "workspace\t%d\n", s.Platform.Workspace)
+	fmt.Fprintf(tw, "user\t%d\n", s.Platform.User)
+	fmt.Fprintf(tw, "globalRoleBinding\t%d\n", s.Platform.GlobalRoleBinding)
+	fmt.Fprintf(tw, "workspaceRoleBinding\t%d\n", s.Platform.WorkspaceRoleBinding)
+	return tw.Flush()
+}
+
+func orNone(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return "<none>"
+	}
+	return s
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	telemetryv1alpha1 "kubesphere.io/telemetry/pkg/apis/telemetry/v1alpha1"
+	"kubesphere.io/telemetry/pkg/telemetry/collector"
+	"kubesphere.io/telemetry/pkg/telemetry/report"
+)
+
+func TestPruneHistory(t *testing.T) {
+	now := time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	newClusterInfo := func(name string, age time.Duration, synced bool) telemetryv1alpha1.ClusterInfo {
+		ts := metav1.NewTime(now.Add(-age))
+		clusterInfo := telemetryv1alpha1.ClusterInfo{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     telemetryv1alpha1.ClusterInfoStatus{TS: &ts},
+		}
+		if synced {
+			clusterInfo.Status.SyncTime = &ts
+		}
+		return clusterInfo
+	}
+	// sorted by the time of collection
+	clusterInfos := []telemetryv1alpha1.ClusterInfo{
+		newClusterInfo("a", 5*day, true),
+		newClusterInfo("b", 4*day, true),
+		newClusterInfo("c", 3*day, true),
+		newClusterInfo("d", 2*day, false),
+		newClusterInfo("e", day, false),
+	}
+
+	tests := []struct {
+		name   string
+		filter historyFilter
+		policy report.RetentionPolicy
+		dryRun bool
+		// missing the ClusterInfo which are not stored, so that they fail to delete
+		missing    []string
+		wantPruned []string
+		wantKept   int
+		wantErr    bool
+		wantStored []string
+	}{
+		{
+			name:       "limits apply to the selected only",
+			filter:     historyFilter{synced: true},
+			policy:     report.RetentionPolicy{MaxCount: 2},
+			wantPruned: []string{"a"},
+			wantKept:   2,
+			wantStored: []string{"b", "c", "d", "e"},
+		},
+		{
+			name:       "unselected never pruned",
+			filter:     historyFilter{until: "36h"},
+			policy:     report.RetentionPolicy{MaxAge: 2 * day, PruneUnsynced: true},
+			wantPruned: []string{"a", "b", "c"},
+			wantKept:   1,
+			wantStored: []string{"d", "e"},
+		},
+		{
+			name:       "no filter",
+			policy:     report.RetentionPolicy{MaxCount: 2},
+			wantPruned: []string{"a", "b", "c"},
+			wantKept:   2,
+			wantStored: []string{"d", "e"},
+		},
+		{
+			name:       "dry run",
+			filter:     historyFilter{synced: true},
+			policy:     report.RetentionPolicy{MaxCount: 1},
+			dryRun:     true,
+			wantPruned: []string{"a", "b"},
+			wantKept:   1,
+			wantStored: []string{"a", "b", "c", "d", "e"},
+		},
+		{
+			name:       "failed to delete",
+			filter:     historyFilter{synced: true},
+			policy:     report.RetentionPolicy{MaxCount: 1},
+			missing:    []string{"a"},
+			wantPruned: []string{"b"},
+			wantKept:   2,
+			wantErr:    true,
+			wantStored: []string{"c", "d", "e"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := fake.NewClientBuilder().WithScheme(collector.Schema)
+			for i := range clusterInfos {
+				if !slices.Contains(tt.missing, clusterInfos[i].Name) {
+					builder.WithObjects(clusterInfos[i].DeepCopy())
+				}
+			}
+			client := builder.Build()
+			match, err := tt.filter.match(now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			summary, err := pruneHistory(context.Background(), client, io.Discard, tt.policy, clusterInfos, match, now, tt.dryRun)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("pruneHistory() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var pruned []string
+			for _, p := range summary.Pruned {
+				pruned = append(pruned, p.ClusterInfo.Name)
+			}
+			if !reflect.DeepEqual(pruned, tt.wantPruned) {
+				t.Errorf("pruned = %v, want %v", pruned, tt.wantPruned)
+			}
+			if summary.Kept != tt.wantKept {
+				t.Errorf("kept = %d, want %d", summary.Kept, tt.wantKept)
+			}
+			stored, err := report.ListClusterInfos(context.Background(), client)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, clusterInfo := range stored {
+				names = append(names, clusterInfo.Name)
+			}
+			if !reflect.DeepEqual(names, tt.wantStored) {
+				t.Errorf("stored = %v, want %v", names, tt.wantStored)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	"kubesphere.io/telemetry/pkg/telemetry/collector"
+)
+
+// leaderElectionOptions make sure only one replica collects and syncs data when telemetry runs as a Deployment.
+type leaderElectionOptions struct {
+	// leaderElect whether to use leader election in long-running mode.
+	leaderElect bool
+	// namespace the namespace of the Lease. the namespace of the pod is used when it's empty.
+	namespace string
+	// name the name of the Lease.
+	name          string
+	leaseDuration time.Duration
+	renewDeadline time.Duration
+	retryPeriod   time.Duration
+}
+
+func defaultLeaderElectionOptions() *leaderElectionOptions {
+	return &leaderElectionOptions{
+		name:          "telemetry",
+		leaseDuration: 15 * time.Second,
+		renewDeadline: 10 * time.Second,
+		retryPeriod:   2 * time.Second,
+	}
+}
+
+func (o *leaderElectionOptions) addFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&o.leaderElect, "leader-elect", o.leaderElect, "use a Lease to make sure only one replica collects data in long-running mode")
+	fs.StringVar(&o.namespace, "leader-election-namespace", o.namespace, "the namespace of the Lease. the namespace of the pod is used when it's empty")
+	fs.StringVar(&o.name, "leader-election-name", o.name, "the name of the Lease")
+	fs.DurationVar(&o.leaseDuration, "leader-election-lease-duration", o.leaseDuration, "how long the non-leader replicas wait before taking over the Lease")
+	fs.DurationVar(&o.renewDeadline, "leader-election-renew-deadline", o.renewDeadline, "how long the leader retries to renew the Lease before giving up")
+	fs.DurationVar(&o.retryPeriod, "leader-election-retry-period", o.retryPeriod, "the interval between two attempts to acquire or renew the Lease")
+}
+
+func (o *leaderElectionOptions) validate() error {
+	if !o.leaderElect {
+		return nil
+	}
+	if o.name == "" {
+		return fmt.Errorf("--leader-election-name is required")
+	}
+	if o.leaseDuration <= o.renewDeadline {
+		return fmt.Errorf("--leader-election-lease-duration must be greater than --leader-election-renew-deadline")
+	}
+	if o.renewDeadline <= o.retryPeriod {
+		return fmt.Errorf("--leader-election-renew-deadline must be greater than --leader-election-retry-period")
+	}
+	return nil
+}
+
+// run calls f after the Lease is acquired. ctx of f is canceled when the leadership is lost,
+// and run returns an error so that the process exits and restarts as a candidate.
+func (o *leaderElectionOptions) run(ctx context.Context, restConfig *rest.Config, f func(ctx context.Context) error) error {
+	if !o.leaderElect {
+		return f(ctx)
+	}
+	mgr, err := manager.New(restConfig, manager.Options{
+		Scheme:                        collector.Schema,
+		Metrics:                       metricsserver.Options{BindAddress: "0"},
+		LeaderElection:                true,
+		LeaderElectionResourceLock:    resourcelock.LeasesResourceLock,
+		LeaderElectionNamespace:       o.namespace,
+		LeaderElectionID:              o.name,
+		LeaderElectionReleaseOnCancel: true,
+		LeaseDuration:                 &o.leaseDuration,
+		RenewDeadline:                 &o.renewDeadline,
+		RetryPeriod:                   &o.retryPeriod,
+	})
+	if err != nil {
+		return err
+	}
+	if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		klog.Infof("acquired lease %s. start telemetry", o.name)
+		return f(ctx)
+	})); err != nil {
+		return err
+	}
+	return mgr.Start(ctx)
+}
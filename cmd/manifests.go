@@ -0,0 +1,65 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"kubesphere.io/telemetry/pkg/telemetry/manifests"
+)
+
+func manifestsCmd(version string) *cobra.Command {
+	o := defaultTelemetryOptions()
+	// the manifests are rendered from the flags and the file only, the env of the local shell is not baked in.
+	o.ignoreEnv = true
+	m := manifests.NewOptions()
+	m.Image = manifests.DefaultImage + ":" + version
+	cmd := &cobra.Command{
+		Use:   "manifests",
+		Short: "Render the manifests to deploy telemetry",
+		Long: "Render the CRD, ServiceAccount, rbac, ConfigMap and CronJob (or Deployment when --interval is set) to deploy telemetry. " +
+			"the ClusterRole is derived from the resources which the registered collectors read.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if o.configMap != "" {
+				return fmt.Errorf("--config-map is not supported. render the manifests from the flags and --config")
+			}
+			cfg, err := o.complete(cmd.Context(), nil)
+			if err != nil {
+				return err
+			}
+			if m.Replicas < 1 {
+				return fmt.Errorf("--replicas must be greater than 0")
+			}
+			m.Config = *cfg
+			objects, err := manifests.Render(m)
+			if err != nil {
+				return err
+			}
+			return manifests.Write(cmd.OutOrStdout(), objects)
+		},
+	}
+	o.addConfigFlags(cmd.Flags())
+	cmd.Flags().StringVar(&m.Name, "name", m.Name, "the name of the ServiceAccount, rbac, ConfigMap and CronJob or Deployment")
+	cmd.Flags().StringVarP(&m.Namespace, "namespace", "n", m.Namespace, "the namespace which telemetry is deployed in")
+	cmd.Flags().StringVar(&m.Image, "image", m.Image, "the image of telemetry")
+	cmd.Flags().StringVar(&m.Schedule, "schedule", m.Schedule, "the schedule of the CronJob when --interval is zero")
+	cmd.Flags().Int32Var(&m.Replicas, "replicas", m.Replicas, "the replicas of the Deployment when --interval is set. leader election is enabled when it's more than 1")
+	return cmd
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+	clusterv1alpha1 "kubesphere.io/api/cluster/v1alpha1"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	"kubesphere.io/telemetry/pkg/telemetry"
+	"kubesphere.io/telemetry/pkg/telemetry/collector"
+	telemetryconfig "kubesphere.io/telemetry/pkg/telemetry/config"
+	"kubesphere.io/telemetry/pkg/telemetry/preflight"
+	"kubesphere.io/telemetry/pkg/telemetry/report"
+)
+
+func preflightCmd() *cobra.Command {
+	o := defaultTelemetryOptions()
+	cmd := &cobra.Command{
+		Use:   "preflight",
+		Short: "Check the permissions which telemetry needs",
+		Long: "Check every permission which the registered collectors and the report need by SelfSubjectAccessReview. " +
+			"the resources in each cluster are checked with the kubeconfig of the cluster.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			restConfig, err := config.GetConfig()
+			if err != nil {
+				return err
+			}
+			cfg, err := o.complete(ctx, restConfig)
+			if err != nil {
+				// check with the flags and env. the configuration may be unreadable because of the missing permissions.
+				fmt.Fprintf(cmd.ErrOrStderr(), "warning: load configuration error %v\n", err)
+				cfg = &o.Config
+			}
+			kubeClient, err := kubernetes.NewForConfig(restConfig)
+			if err != nil {
+				return err
+			}
+			client, err := runtimeclient.New(restConfig, runtimeclient.Options{Scheme: collector.Schema})
+			if err != nil {
+				return err
+			}
+
+			host, inClusters := preflight.Requirements(o.requiredResources(cfg))
+			results := preflight.Check(ctx, "telemetry", kubeClient, host)
+			if len(inClusters) != 0 {
+				clusterList := &clusterv1alpha1.ClusterList{}
+				if err := client.List(ctx, clusterList); err != nil {
+					fmt.Fprintf(cmd.ErrOrStderr(), "warning: list cluster error %v\n", err)
+				}
+				for _, cluster := range clusterList.Items {
+					name := "cluster/" + cluster.Name
+					clusterClient, err := collector.ClusterKubeClient(cluster)
+					if err != nil {
+						results = append(results, preflight.Result{Client: name, Err: err})
+						continue
+					}
+					results = append(results, preflight.Check(ctx, name, clusterClient, inClusters)...)
+				}
+			}
+
+			var failed int
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
+			fmt.Fprintln(w, "CLIENT\tRESOURCE\tNAMESPACE\tVERBS\tUSED BY\tRESULT\tMISSING")
+			for _, r := range results {
+				result, missing := "pass", ""
+				if !r.Passed() {
+					failed++
+					result = "fail"
+					missing = strings.Join(r.Missing, ",")
+					if r.Err != nil {
+						result, missing = "error", r.Err.Error()
+					}
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+					r.Client, resourceString(r.Resource), orAll(r.Namespace), strings.Join(r.Verbs, ","),
+					strings.Join(r.Users, ","), result, missing)
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			if failed != 0 {
+				return fmt.Errorf("%d of %d permission checks failed", failed, len(results))
+			}
+			return nil
+		},
+	}
+	o.addFlags(cmd.Flags())
+	return cmd
+}
+
+// requiredResources returns the resources which telemetry accesses, keyed by who needs them.
+func (o *telemetryOptions) requiredResources(cfg *telemetryconfig.Config) map[string][]collector.Resource {
+	resources := map[string][]collector.Resource{"telemetry": telemetry.Resources()}
+	for _, c := range collector.Registered {
+		resources[c.RecordKey()] = collector.RequirementsOf(c).Resources
+	}
+	if cfg.URL != "" {
+		var secret *types.NamespacedName
+		if cfg.CloudSecret != "" {
+			secret = ptr.To(telemetryconfig.ParseNamespacedName(cfg.CloudSecret))
+		}
+		resources["report"] = report.CloudResources(secret)
+	}
+	if o.configMap != "" {
+		cm := telemetryconfig.ParseNamespacedName(o.configMap)
+		resources["config"] = []collector.Resource{{
+			Resource:      "configmaps",
+			Verbs:         []string{collector.VerbGet},
+			Namespace:     cm.Namespace,
+			ResourceNames: []string{cm.Name},
+		}}
+	}
+	if o.leaderElection.leaderElect {
+		// the same namespace as the Lease which telemetry uses in the pod deployed by the manifests or chart.
+		namespace := o.leaderElection.namespace
+		if namespace == "" {
+			namespace = os.Getenv(envPodNamespace)
+		}
+		if namespace == "" {
+			namespace = telemetryconfig.DefaultNamespace
+		}
+		resources["leader-election"] = []collector.Resource{{
+			Group:     "coordination.k8s.io",
+			Resource:  "leases",
+			Verbs:     []string{collector.VerbGet, collector.VerbCreate, collector.VerbUpdate},
+			Namespace: namespace,
+		}}
+	}
+	return resources
+}
+
+// resourceString formats the resource as resource.group/names.
+func resourceString(r collector.Resource) string {
+	s := r.Resource
+	if r.Group != "" {
+		s += "." + r.Group
+	}
+	if len(r.ResourceNames) != 0 {
+		s += "/" + strings.Join(r.ResourceNames, ",")
+	}
+	return s
+}
+
+func orAll(namespace string) string {
+	if namespace == "" {
+		return "*"
+	}
+	return namespace
+}
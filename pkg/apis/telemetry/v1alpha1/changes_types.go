@@ -0,0 +1,48 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+type Changes struct {
+	// ts of the previous clusterInfo
+	Since string `json:"since"`
+	// lifecycle of clusters seen in the history
+	Clusters []ClusterLifecycle `json:"clusters"`
+	// events since the previous clusterInfo
+	Events []ChangeEvent `json:"events"`
+}
+
+type ClusterLifecycle struct {
+	// cluster name
+	Name string `json:"name"`
+	// cluster uid
+	Uid string `json:"uid"`
+	// ts of the first clusterInfo which contains the cluster
+	FirstSeen string `json:"firstSeen"`
+	// ts of the last clusterInfo which contains the cluster
+	LastSeen string `json:"lastSeen"`
+	// the change of node number since the previous clusterInfo
+	NodeDelta int `json:"nodeDelta"`
+}
+
+type ChangeEvent struct {
+	// ClusterJoined, ClusterLeft, KubeSphereUpgraded, KubernetesUpgraded or NodesChanged
+	Type string `json:"type"`
+	// cluster name
+	Cluster string `json:"cluster"`
+	From    string `json:"from,omitempty"`
+	To      string `json:"to,omitempty"`
+}
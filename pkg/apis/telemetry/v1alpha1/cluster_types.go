@@ -0,0 +1,129 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+type Cluster struct {
+	// cluster role
+	Role string `json:"role"`
+	// cluster name
+	Name string `json:"name"`
+	// cluster uid
+	Uid string `json:"uid"`
+	// cluster namespace id
+	Nid string `json:"nid"`
+	// kubesphere version
+	KSVersion string `json:"ksVersion"`
+	// kubernetes cluster version
+	ClusterVersion string `json:"clusterVersion"`
+	// Namepace number of cluster
+	Namespace int `json:"namespace"`
+	// nodes of cluster
+	Nodes []Node `json:"nodes"`
+	// aggregate data of nodes
+	NodeSummary NodeSummary `json:"nodeSummary"`
+	// kubernetes distribution inferred from evidence. e.g. eks, ack, k3s, kubernetes
+	Distribution string `json:"distribution"`
+	// cloud provider inferred from evidence. e.g. aws, alibaba, unknown
+	Provider string `json:"provider"`
+	// evidence of distribution and provider
+	Evidence []Evidence `json:"evidence"`
+}
+
+type Node struct {
+	// node uid
+	Uid string `json:"uid"`
+	// node name
+	Name string `json:"name"`
+	// node roles
+	Role []string `json:"role"`
+	// node arch
+	Arch string `json:"arch"`
+	// node containerRuntime
+	ContainerRuntime string `json:"containerRuntime"`
+	// node kernel
+	Kernel string `json:"kernel"`
+	// node kubeProxy
+	KubeProxy string `json:"kubeProxy"`
+	// node kubelet
+	Kubelet string `json:"kubelet"`
+	// node operator system
+	Os string `json:"os"`
+	// os operator system image
+	OsImage string `json:"osImage"`
+	// node topology zone
+	Zone string `json:"zone"`
+	// node topology region
+	Region string `json:"region"`
+	// whether node is ready
+	Ready bool `json:"ready"`
+	// status of node conditions by type
+	Conditions map[string]string `json:"conditions"`
+	// node taints
+	Taints []NodeTaint `json:"taints"`
+	// node capacity resources
+	Capacity NodeResources `json:"capacity"`
+	// node allocatable resources
+	Allocatable NodeResources `json:"allocatable"`
+}
+
+type NodeTaint struct {
+	Key    string `json:"key"`
+	Effect string `json:"effect"`
+}
+
+// NodeResources capacity or allocatable resources of node.
+type NodeResources struct {
+	// cpu in millicores
+	CPU int64 `json:"cpu"`
+	// memory in bytes
+	Memory int64 `json:"memory"`
+	// ephemeral storage in bytes
+	EphemeralStorage int64 `json:"ephemeralStorage"`
+	Pods             int64 `json:"pods"`
+	// gpu extended resources by resource name
+	GPU map[string]int64 `json:"gpu,omitempty"`
+}
+
+// NodeSummary aggregate data of nodes in cluster.
+type NodeSummary struct {
+	// node number
+	Total int `json:"total"`
+	// ready node number
+	Ready int `json:"ready"`
+	// total capacity resources of nodes
+	Capacity NodeResources `json:"capacity"`
+	// total allocatable resources of nodes
+	Allocatable NodeResources `json:"allocatable"`
+	// node number by topology zone
+	Zone map[string]int `json:"zone"`
+	// node number by topology region
+	Region map[string]int `json:"region"`
+}
+
+// Evidence why a distribution or provider is detected.
+type Evidence struct {
+	// distribution or provider
+	Field string `json:"field"`
+	// the inferred value
+	Value string `json:"value"`
+	// where the evidence is found. e.g. providerID, kubeletVersion, label, namespace
+	Source string `json:"source"`
+	// the matched pattern
+	Match string `json:"match"`
+	// high, medium or low
+	Confidence string `json:"confidence"`
+}
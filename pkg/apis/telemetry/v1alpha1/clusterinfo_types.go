@@ -0,0 +1,120 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ResourceKindClusterInfo   = "ClusterInfo"
+	ResourcePluralClusterInfo = "clusterinfoes"
+
+	// ConditionTypeCollected the telemetry data is collected and saved in status.
+	ConditionTypeCollected = "Collected"
+	// ConditionTypeSynced the telemetry data is synced to ksCloud.
+	ConditionTypeSynced = "Synced"
+	// ConditionTypeSyncFailed the last attempt to sync to ksCloud failed.
+	ConditionTypeSyncFailed = "SyncFailed"
+
+	// LabelRunID the id of the run which collects the data.
+	LabelRunID = "telemetry.kubesphere.io/run-id"
+	// LabelCollectorSet the hash of the collectors which collect the data.
+	LabelCollectorSet = "telemetry.kubesphere.io/collector-set"
+	// AnnotationCollectors the comma separated record keys of the collectors which collect the data.
+	AnnotationCollectors = "telemetry.kubesphere.io/collectors"
+)
+
+// ClusterInfoSpec nothing in Spec. only use collect cluster telemetry data
+type ClusterInfoSpec struct {
+}
+
+// ClusterInfoStatus store cluster telemetry data
+type ClusterInfoStatus struct {
+	// collection time
+	TS *metav1.Time `json:"ts,omitempty"`
+	// when to sync data to ksCloud
+	SyncTime *metav1.Time `json:"syncTime,omitempty"`
+	// Collected, Synced and SyncFailed
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// number of attempts to sync data to ksCloud
+	SyncAttempts int `json:"syncAttempts,omitempty"`
+	// error of the last failed attempt to sync data to ksCloud
+	LastSyncError string `json:"lastSyncError,omitempty"`
+	// number of consecutive failed attempts to sync data to ksCloud
+	SyncFailures int `json:"syncFailures,omitempty"`
+	// number of 4xx responses of ksCloud. the data won't be synced again when it reaches the limit.
+	SyncClientErrors int `json:"syncClientErrors,omitempty"`
+	// the data won't be synced before this time after a failed attempt
+	NextSyncTime *metav1.Time `json:"nextSyncTime,omitempty"`
+	// number of clusters
+	ClusterCount int `json:"clusterCount,omitempty"`
+	// number of nodes in all clusters
+	NodeCount int `json:"nodeCount,omitempty"`
+	// kubesphere cloud id
+	CloudID string `json:"cloudId,omitempty"`
+	// cluster info which kubesphere use. refer to clusters.cluster.kubesphere.io
+	Clusters []Cluster `json:"clusters,omitempty"`
+	// extension which cluster has installed. refer to subscriptions.kubesphere.io
+	Extension []Extension `json:"extension,omitempty"`
+	// the platform resources total.
+	Platform *Platform `json:"platform,omitempty"`
+	// workloads of each cluster.
+	Workloads []Workload `json:"workloads,omitempty"`
+	// storage of each cluster.
+	Storage []Storage `json:"storage,omitempty"`
+	// network stack of each cluster.
+	Network []Network `json:"network,omitempty"`
+	// extension repositories and available versions of installed extensions.
+	Catalog *Catalog `json:"catalog,omitempty"`
+	// how kubesphere authenticates users.
+	Authentication *Authentication `json:"authentication,omitempty"`
+	// lifecycle of clusters computed from the history and what changed since the previous clusterInfo.
+	Changes *Changes `json:"changes,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Clusters",type="integer",JSONPath=".status.clusterCount"
+// +kubebuilder:printcolumn:name="Nodes",type="integer",JSONPath=".status.nodeCount"
+// +kubebuilder:printcolumn:name="Synced",type="string",JSONPath=".status.conditions[?(@.type==\"Synced\")].status"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// ClusterInfo is the Schema for the clusterinfos API. the API is use to store telemetry data.
+type ClusterInfo struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterInfoSpec   `json:"spec,omitempty"`
+	Status ClusterInfoStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterInfoList contains a list of ClusterInfo
+type ClusterInfoList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterInfo `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterInfo{}, &ClusterInfoList{})
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+type Extension struct {
+	// extension name
+	Name string `json:"name"`
+	// extension version
+	Version string `json:"version"`
+	// extension create time
+	Ctime string `json:"ctime"`
+	// install state of extension in host. e.g. Installed, InstallFailed
+	State string `json:"state"`
+	// whether extension is enabled
+	Enabled bool `json:"enabled"`
+	// the repository which extension comes from
+	Repository string `json:"repository"`
+	// conditions of extension in host
+	Conditions []ExtensionCondition `json:"conditions"`
+	// state transitions of extension, including install and upgrade
+	StateHistory []ExtensionState `json:"stateHistory"`
+	// the member clusters which extension is scheduled to
+	Placement *ExtensionPlacement `json:"placement,omitempty"`
+	// install state of extension in each scheduled member cluster
+	Clusters []ExtensionClusterState `json:"clusters"`
+}
+
+type ExtensionCondition struct {
+	Type   string `json:"type"`
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+	// +kubebuilder:validation:Format=date-time
+	LastTransitionTime string `json:"lastTransitionTime"`
+}
+
+type ExtensionState struct {
+	State string `json:"state"`
+	// +kubebuilder:validation:Format=date-time
+	LastTransitionTime string `json:"lastTransitionTime"`
+}
+
+type ExtensionPlacement struct {
+	Clusters []string `json:"clusters"`
+	// whether extension is scheduled by cluster selector
+	ClusterSelector bool `json:"clusterSelector"`
+}
+
+type ExtensionClusterState struct {
+	Cluster string `json:"cluster"`
+	State   string `json:"state"`
+	// conditions of extension in the cluster
+	Conditions []ExtensionCondition `json:"conditions"`
+}
+
+type Catalog struct {
+	// extension repositories. refer to repositories.kubesphere.io
+	Repositories []ExtensionRepository `json:"repositories"`
+	// available versions of installed extensions
+	Extensions []CatalogExtension `json:"extensions"`
+}
+
+// ExtensionRepository the url of repository is not collected.
+type ExtensionRepository struct {
+	// repository name
+	Name string `json:"name"`
+	// whether it's maintained by kubesphere
+	Official bool `json:"official"`
+	// whether the repository requires authentication
+	BasicAuth bool `json:"basicAuth"`
+	// number of extensions in the repository
+	Extension    int    `json:"extension"`
+	LastSyncTime string `json:"lastSyncTime"`
+}
+
+type CatalogExtension struct {
+	// extension name
+	Name string `json:"name"`
+	// the repository which extension comes from
+	Repository       string `json:"repository"`
+	InstalledVersion string `json:"installedVersion"`
+	LatestVersion    string `json:"latestVersion"`
+	// number of versions in repository
+	AvailableVersion int `json:"availableVersion"`
+	// number of available versions newer than the installed one
+	VersionsBehind int `json:"versionsBehind"`
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+// Platform statistics tenancy data of platform. the name of workspace, user and role is not collected.
+type Platform struct {
+	// workspace number of cluster
+	Workspace int `json:"workspace"`
+	// user number of cluster
+	User int `json:"user"`
+	// number of workspaces by the number of clusters they are placed in. clusterSelector is for workspaces placed by cluster selector
+	WorkspacePlacement map[string]int `json:"workspacePlacement"`
+	// distribution of namespace number per workspace in all clusters
+	NamespacePerWorkspace Distribution `json:"namespacePerWorkspace"`
+	// number of users by state. e.g. Active, Disabled, Pending
+	UserState map[string]int `json:"userState"`
+	// number of users by the kind of identity provider. local is for users created in kubesphere, external is for the others
+	UserIdentityProvider map[string]int `json:"userIdentityProvider"`
+	// globalRoleBinding number of platform
+	GlobalRoleBinding int `json:"globalRoleBinding"`
+	// workspaceRoleBinding number of platform
+	WorkspaceRoleBinding int `json:"workspaceRoleBinding"`
+	// number of roles created by users by kind. e.g. GlobalRole, WorkspaceRole
+	CustomRole map[string]int `json:"customRole"`
+}
+
+type Distribution struct {
+	Min    int `json:"min"`
+	Median int `json:"median"`
+	Max    int `json:"max"`
+}
+
+type Authentication struct {
+	// number of identity providers by type. e.g. LDAPIdentityProvider, OIDCIdentityProvider
+	IdentityProvider map[string]int `json:"identityProvider"`
+	// total number of identity providers
+	IdentityProviders int `json:"identityProviders"`
+	// whether a user can login from multiple places
+	MultipleLogin bool `json:"multipleLogin"`
+	// whether login history is retained
+	LoginHistory                bool   `json:"loginHistory"`
+	LoginHistoryRetentionPeriod string `json:"loginHistoryRetentionPeriod"`
+	LoginHistoryMaximumEntries  int    `json:"loginHistoryMaximumEntries"`
+	// whether multi-factor authentication is enabled
+	MFA bool `json:"mfa"`
+	// whether authenticate rate limiter is enabled
+	RateLimiter bool `json:"rateLimiter"`
+}
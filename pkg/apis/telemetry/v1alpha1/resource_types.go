@@ -0,0 +1,106 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+type Workload struct {
+	// cluster name
+	Cluster string `json:"cluster"`
+	// deployment number of cluster
+	Deployment int `json:"deployment"`
+	// statefulSet number of cluster
+	StatefulSet int `json:"statefulSet"`
+	// daemonSet number of cluster
+	DaemonSet int `json:"daemonSet"`
+	// job number of cluster
+	Job int `json:"job"`
+	// cronJob number of cluster
+	CronJob int `json:"cronJob"`
+	// pod number of cluster by phase
+	Pod map[string]int `json:"pod"`
+	// service number of cluster by type
+	Service map[string]int `json:"service"`
+}
+
+type Storage struct {
+	// cluster name
+	Cluster string `json:"cluster"`
+	// storage classes of cluster
+	StorageClasses []StorageClass `json:"storageClasses"`
+	// persistentVolume number of cluster
+	PersistentVolume int `json:"persistentVolume"`
+	// persistentVolumeClaim number of cluster
+	PersistentVolumeClaim int `json:"persistentVolumeClaim"`
+	// total requested storage of persistentVolumeClaim in bytes
+	RequestedStorage int64 `json:"requestedStorage"`
+	// csi drivers installed in cluster
+	CSIDrivers []string `json:"csiDrivers"`
+}
+
+type StorageClass struct {
+	// storage class name
+	Name string `json:"name"`
+	// storage class provisioner
+	Provisioner string `json:"provisioner"`
+	// whether it's the default storage class
+	Default bool `json:"default"`
+	// storage class reclaim policy
+	ReclaimPolicy string `json:"reclaimPolicy"`
+	// storage class volume binding mode
+	VolumeBindingMode string `json:"volumeBindingMode"`
+	// persistentVolume number of the storage class
+	PersistentVolume int `json:"persistentVolume"`
+	// persistentVolumeClaim number of the storage class
+	PersistentVolumeClaim int `json:"persistentVolumeClaim"`
+	// total requested storage of persistentVolumeClaim in the storage class in bytes
+	RequestedStorage int64 `json:"requestedStorage"`
+}
+
+type Network struct {
+	// cluster name
+	Cluster string `json:"cluster"`
+	// cni plugins of cluster
+	CNI []string `json:"cni"`
+	// kube-proxy mode. iptables, ipvs, nftables, replaced or unknown
+	KubeProxyMode string `json:"kubeProxyMode"`
+	// ingress controllers of cluster
+	IngressControllers []string `json:"ingressControllers"`
+	// ingress classes of cluster
+	IngressClasses []IngressClass `json:"ingressClasses"`
+	// served versions of gateway api
+	GatewayAPI []string `json:"gatewayAPI"`
+	// size of pod cidr
+	ClusterCIDR []NetworkCIDR `json:"clusterCIDR"`
+	// size of service cidr
+	ServiceCIDR []NetworkCIDR `json:"serviceCIDR"`
+}
+
+type IngressClass struct {
+	// ingress class name
+	Name string `json:"name"`
+	// ingress class controller
+	Controller string `json:"controller"`
+	// whether it's the default ingress class
+	Default bool `json:"default"`
+}
+
+// NetworkCIDR size of cidr. the address is not collected.
+type NetworkCIDR struct {
+	// IPv4 or IPv6
+	Family string `json:"family"`
+	// prefix length of cidr
+	Prefix int `json:"prefix"`
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	telemetryv1alpha1 "kubesphere.io/telemetry/pkg/apis/telemetry/v1alpha1"
+)
+
+// collector how kubesphere authenticates users. only types and switches are collected, never secrets or urls.
+// the identity providers stored in Secrets are not collected, since reading them needs to list all the Secrets
+// in kubesphere-system, e.g. the credentials of ksCloud.
+
+const (
+	kubesphereNamespace = "kubesphere-system"
+	// kubesphereConfigName the ConfigMap of ks-apiserver configuration
+	kubesphereConfigName = "kubesphere-config"
+	kubesphereConfigKey  = "kubesphere.yaml"
+)
+
+func init() {
+	register(&Authentication{})
+}
+
+type Authentication telemetryv1alpha1.Authentication
+
+// identityProviderOptions only the fields without secrets are decoded.
+type identityProviderOptions struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type authenticationOptions struct {
+	AuthenticateRateLimiterMaxTries int     `json:"authenticateRateLimiterMaxTries"`
+	LoginHistoryRetentionPeriod     *string `json:"loginHistoryRetentionPeriod"`
+	LoginHistoryMaximumEntries      int     `json:"loginHistoryMaximumEntries"`
+	MultipleLogin                   bool    `json:"multipleLogin"`
+	MultiFactorAuthentication       *struct {
+		Enabled bool `json:"enabled"`
+	} `json:"multiFactorAuthentication"`
+	OAuthOptions struct {
+		// IdentityProviders configured in kubesphere-config.
+		IdentityProviders []identityProviderOptions `json:"identityProviders"`
+	} `json:"oauthOptions"`
+}
+
+func (a Authentication) RecordKey() string {
+	return "authentication"
+}
+
+// Requirements implements Requirer.
+func (a Authentication) Requirements() Requirements {
+	return Requirements{
+		Resources: []Resource{
+			{Resource: "configmaps", Verbs: []string{VerbGet}, Namespace: kubesphereNamespace, ResourceNames: []string{kubesphereConfigName}},
+		},
+		Scope: ScopeHost,
+		Cost:  CostLow,
+	}
+}
+
+func (a Authentication) Collect(ctx context.Context, client runtimeclient.Client) (interface{}, error) {
+	res := Authentication{
+		IdentityProvider: make(map[string]int),
+	}
+	providers := make(map[string]string)
+
+	// authentication options in kubesphere-config
+	cm := &corev1.ConfigMap{}
+	if err := client.Get(ctx, types.NamespacedName{Namespace: kubesphereNamespace, Name: kubesphereConfigName}, cm); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("get kubesphere config error %v", err)
+		}
+	} else {
+		config := struct {
+			Authentication authenticationOptions `json:"authentication"`
+		}{}
+		if err := yaml.Unmarshal([]byte(cm.Data[kubesphereConfigKey]), &config); err != nil {
+			klog.Errorf("unmarshal kubesphere config error %v", err)
+		}
+		options := config.Authentication
+		for _, p := range options.OAuthOptions.IdentityProviders {
+			providers[p.Name] = p.Type
+		}
+		res.MultipleLogin = options.MultipleLogin
+		res.RateLimiter = options.AuthenticateRateLimiterMaxTries > 0
+		res.LoginHistoryMaximumEntries = options.LoginHistoryMaximumEntries
+		if options.LoginHistoryRetentionPeriod != nil {
+			res.LoginHistoryRetentionPeriod = *options.LoginHistoryRetentionPeriod
+			if d, err := time.ParseDuration(*options.LoginHistoryRetentionPeriod); err == nil && d > 0 {
+				res.LoginHistory = true
+			}
+		}
+		res.MFA = options.MultiFactorAuthentication != nil && options.MultiFactorAuthentication.Enabled
+	}
+
+	res.IdentityProviders = len(providers)
+	for _, t := range providers {
+		res.IdentityProvider[t]++
+	}
+	return res, nil
+}
@@ -21,11 +21,14 @@ import (
 
 	"k8s.io/apimachinery/pkg/runtime"
 	runtimeutil "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	clusterv1alpha1 "kubesphere.io/api/cluster/v1alpha1"
 	corev1alpha1 "kubesphere.io/api/core/v1alpha1"
 	iamv1beta1 "kubesphere.io/api/iam/v1beta1"
 	tenantv1beta1 "kubesphere.io/api/tenant/v1beta1"
 	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	telemetryv1alpha1 "kubesphere.io/telemetry/pkg/apis/telemetry/v1alpha1"
 )
 
 var Registered []Collector
@@ -46,8 +49,10 @@ var Schema = runtime.NewScheme()
 
 func init() {
 	// register scheme
+	runtimeutil.Must(clientgoscheme.AddToScheme(Schema))
 	runtimeutil.Must(clusterv1alpha1.AddToScheme(Schema))
 	runtimeutil.Must(corev1alpha1.AddToScheme(Schema))
 	runtimeutil.Must(tenantv1beta1.AddToScheme(Schema))
 	runtimeutil.Must(iamv1beta1.AddToScheme(Schema))
+	runtimeutil.Must(telemetryv1alpha1.AddToScheme(Schema))
 }
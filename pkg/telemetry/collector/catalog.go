@@ -0,0 +1,178 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/version"
+	corev1alpha1 "kubesphere.io/api/core/v1alpha1"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	telemetryv1alpha1 "kubesphere.io/telemetry/pkg/apis/telemetry/v1alpha1"
+)
+
+// collector extension repositories and how far behind installed extensions are.
+
+var (
+	// officialRepositoryHosts hosts of the repositories maintained by kubesphere.
+	officialRepositoryHosts = []string{
+		"extensions-museum.kubesphere-system.svc",
+		"app.kubesphere.cloud",
+		"charts.kubesphere.io",
+	}
+	// officialRepositoryImages images of the repositories maintained by kubesphere.
+	officialRepositoryImages = []string{
+		"kubesphere/ks-extensions-museum",
+	}
+)
+
+func init() {
+	register(&Catalog{})
+}
+
+type Catalog telemetryv1alpha1.Catalog
+
+type (
+	ExtensionRepository = telemetryv1alpha1.ExtensionRepository
+	CatalogExtension    = telemetryv1alpha1.CatalogExtension
+)
+
+func (c Catalog) RecordKey() string {
+	return "catalog"
+}
+
+// Requirements implements Requirer.
+func (c Catalog) Requirements() Requirements {
+	return Requirements{
+		Resources: []Resource{
+			{Group: "kubesphere.io", Resource: "repositories", Verbs: []string{VerbList}},
+			{Group: "kubesphere.io", Resource: "extensions", Verbs: []string{VerbList}},
+			{Group: "kubesphere.io", Resource: "extensionversions", Verbs: []string{VerbList}},
+			{Group: "kubesphere.io", Resource: "installplans", Verbs: []string{VerbList}},
+		},
+		Scope: ScopeHost,
+		Cost:  CostMedium,
+	}
+}
+
+func (c Catalog) Collect(ctx context.Context, client runtimeclient.Client) (interface{}, error) {
+	repositoryList := &corev1alpha1.RepositoryList{}
+	if err := client.List(ctx, repositoryList); err != nil {
+		return nil, fmt.Errorf("get RepositoryList error %v", err)
+	}
+	extensionList := &corev1alpha1.ExtensionList{}
+	if err := client.List(ctx, extensionList); err != nil {
+		return nil, fmt.Errorf("get ExtensionList error %v", err)
+	}
+	extensionVersionList := &corev1alpha1.ExtensionVersionList{}
+	if err := client.List(ctx, extensionVersionList); err != nil {
+		return nil, fmt.Errorf("get ExtensionVersionList error %v", err)
+	}
+	installPlanList := &corev1alpha1.InstallPlanList{}
+	if err := client.List(ctx, installPlanList); err != nil {
+		return nil, fmt.Errorf("get InstallPlanList error %v", err)
+	}
+
+	// number of extensions by repository
+	extensionCount := make(map[string]int)
+	repositories := make(map[string]string)
+	for _, ext := range extensionList.Items {
+		repository := ext.Labels[corev1alpha1.RepositoryReferenceLabel]
+		extensionCount[repository]++
+		repositories[ext.Name] = repository
+	}
+	// available versions by extension
+	versions := make(map[string][]string)
+	for _, ev := range extensionVersionList.Items {
+		name := ev.Labels[corev1alpha1.ExtensionReferenceLabel]
+		versions[name] = append(versions[name], ev.Spec.Version)
+	}
+
+	res := Catalog{
+		Repositories: make([]ExtensionRepository, len(repositoryList.Items)),
+		Extensions:   make([]CatalogExtension, len(installPlanList.Items)),
+	}
+	for i, repo := range repositoryList.Items {
+		res.Repositories[i] = ExtensionRepository{
+			Name:      repo.Name,
+			Official:  isOfficialRepository(repo),
+			BasicAuth: repo.Spec.BasicAuth != nil,
+			Extension: extensionCount[repo.Name],
+		}
+		if repo.Status.LastSyncTime != nil {
+			res.Repositories[i].LastSyncTime = repo.Status.LastSyncTime.UTC().Format(time.RFC3339)
+		}
+	}
+	for i, plan := range installPlanList.Items {
+		name := plan.Spec.Extension.Name
+		res.Extensions[i] = CatalogExtension{
+			Name:             name,
+			Repository:       repositories[name],
+			InstalledVersion: plan.Spec.Extension.Version,
+			AvailableVersion: len(versions[name]),
+		}
+		res.Extensions[i].LatestVersion, res.Extensions[i].VersionsBehind = compareVersions(plan.Spec.Extension.Version, versions[name])
+	}
+	return res, nil
+}
+
+func isOfficialRepository(repo corev1alpha1.Repository) bool {
+	if repo.Spec.URL != "" {
+		if u, err := url.Parse(repo.Spec.URL); err == nil {
+			for _, host := range officialRepositoryHosts {
+				if u.Hostname() == host {
+					return true
+				}
+			}
+		}
+	}
+	for _, image := range officialRepositoryImages {
+		if strings.Contains(repo.Spec.Image, image) {
+			return true
+		}
+	}
+	return false
+}
+
+// compareVersions returns the latest of available versions and the number of versions newer than installed.
+// versions which are not semantic are ignored.
+func compareVersions(installed string, available []string) (string, int) {
+	installedVersion, err := version.ParseSemantic(installed)
+	var latest *version.Version
+	behind := 0
+	for _, v := range available {
+		availableVersion, parseErr := version.ParseSemantic(v)
+		if parseErr != nil {
+			continue
+		}
+		if latest == nil || latest.LessThan(availableVersion) {
+			latest = availableVersion
+		}
+		if err == nil && installedVersion.LessThan(availableVersion) {
+			behind++
+		}
+	}
+	if latest == nil {
+		return "", 0
+	}
+	return latest.String(), behind
+}
@@ -23,6 +23,7 @@ import (
 
 	"k8s.io/apimachinery/pkg/util/json"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
@@ -30,6 +31,8 @@ import (
 	"k8s.io/utils/ptr"
 	clusterv1alpha1 "kubesphere.io/api/cluster/v1alpha1"
 	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	telemetryv1alpha1 "kubesphere.io/telemetry/pkg/apis/telemetry/v1alpha1"
 )
 
 // collector cluster data
@@ -38,38 +41,33 @@ func init() {
 	register(&Cluster{})
 }
 
-type Cluster struct {
-	Role           string `json:"role"`
-	Name           string `json:"name"`
-	Uid            string `json:"uid"`
-	Nid            string `json:"nid"`
-	KSVersion      string `json:"ksVersion"`
-	ClusterVersion string `json:"clusterVersion"`
-	Namespace      int    `json:"namespace"`
-	Nodes          []Node `json:"nodes"`
-}
+type Cluster telemetryv1alpha1.Cluster
 
-type Node struct {
-	Uid              string   `json:"uid"`
-	Name             string   `json:"name"`
-	Role             []string `json:"role"`
-	Arch             string   `json:"arch"`
-	ContainerRuntime string   `json:"containerRuntime"`
-	Kernel           string   `json:"kernel"`
-	KubeProxy        string   `json:"kubeProxy"`
-	Kubelet          string   `json:"kubelet"`
-	Os               string   `json:"os"`
-	OsImage          string   `json:"osImage"`
-}
+type Node = telemetryv1alpha1.Node
 
 func (c Cluster) RecordKey() string {
 	return "clusters"
 }
 
+// Requirements implements Requirer.
+func (c Cluster) Requirements() Requirements {
+	return Requirements{
+		Resources: []Resource{
+			clustersResource,
+			{Resource: "namespaces", Verbs: []string{VerbList}, InClusters: true},
+			{Resource: "nodes", Verbs: []string{VerbList}, InClusters: true},
+			// version of ks-apiserver
+			{Resource: "services/proxy", Verbs: []string{VerbGet}, Namespace: kubesphereNamespace, ResourceNames: []string{":ks-apiserver:"}, InClusters: true},
+		},
+		Scope: ScopeMember,
+		Cost:  CostHigh,
+	}
+}
+
 func (c Cluster) Collect(ctx context.Context, client runtimeclient.Client) (interface{}, error) {
 	var clusterList = &clusterv1alpha1.ClusterList{}
 	if err := client.List(ctx, clusterList); err != nil {
-		return c, nil
+		return nil, fmt.Errorf("list cluster error %v", err)
 	}
 	// statistics cluster Data
 	resCluster := make([]Cluster, len(clusterList.Items))
@@ -87,24 +85,33 @@ func (c Cluster) Collect(ctx context.Context, client runtimeclient.Client) (inte
 		} else {
 			resCluster[i].Role = "member"
 		}
-		kubeClient, err := c.getKubeClient(cluster.Spec.Connection.KubeConfig)
+		kubeClient, err := getKubeClient(cluster.Spec.Connection.KubeConfig)
 		if err != nil {
 			return nil, fmt.Errorf("get kube client from cluster %v error %v", cluster.Name, err)
 		}
-		resCluster[i].Namespace = c.getNamespace(ctx, kubeClient)
-		resCluster[i].Nodes = c.getNodes(ctx, kubeClient)
+		namespaces := c.getNamespace(ctx, kubeClient)
+		resCluster[i].Namespace = len(namespaces)
+		var nodes []corev1.Node
+		resCluster[i].Nodes, nodes = c.getNodes(ctx, kubeClient)
+		resCluster[i].NodeSummary = summarizeNodes(resCluster[i].Nodes)
+		resCluster[i].Distribution, resCluster[i].Provider, resCluster[i].Evidence = detectDistribution(nodes, namespaces)
 		resCluster[i].KSVersion, resCluster[i].ClusterVersion = c.getVersion(ctx, kubeClient, cluster)
 	}
 	return resCluster, nil
 }
 
-func (c Cluster) getKubeClient(config []byte) (kubernetes.Interface, error) {
+// ClusterKubeClient returns the kube client of cluster which collectors use to read the resources in cluster.
+func ClusterKubeClient(cluster clusterv1alpha1.Cluster) (kubernetes.Interface, error) {
+	return getKubeClient(cluster.Spec.Connection.KubeConfig)
+}
+
+// getKubeClient returns the kube client of cluster by the kubeconfig in cluster connection.
+func getKubeClient(config []byte) (kubernetes.Interface, error) {
 	clientConfig, err := clientcmd.NewClientConfigFromBytes(config)
 	if err != nil {
 		return nil, err
 	}
 	restConfig, err := clientConfig.ClientConfig()
-	restConfig.Host = "https://172.31.19.43:6443" // todo for debug
 	if err != nil {
 		klog.Errorf("get cluster rest config error %v", err)
 		return nil, err
@@ -116,20 +123,45 @@ func (c Cluster) getKubeClient(config []byte) (kubernetes.Interface, error) {
 	}
 	return kubeClient, nil
 }
-func (c Cluster) getNamespace(ctx context.Context, kubeClient kubernetes.Interface) int {
+
+// forEachCluster calls fn with the kube client of each cluster managed by host.
+// the cluster which kube client can't be created is skipped.
+func forEachCluster(ctx context.Context, client runtimeclient.Client, fn func(cluster clusterv1alpha1.Cluster, kubeClient kubernetes.Interface)) error {
+	var clusterList = &clusterv1alpha1.ClusterList{}
+	if err := client.List(ctx, clusterList); err != nil {
+		return err
+	}
+	for _, cluster := range clusterList.Items {
+		kubeClient, err := getKubeClient(cluster.Spec.Connection.KubeConfig)
+		if err != nil {
+			klog.Errorf("get kube client from cluster %v error %v", cluster.Name, err)
+			continue
+		}
+		fn(cluster, kubeClient)
+	}
+	return nil
+}
+
+// getNamespace returns the name of namespaces.
+func (c Cluster) getNamespace(ctx context.Context, kubeClient kubernetes.Interface) []string {
 	namespaceList, err := kubeClient.CoreV1().Namespaces().List(ctx, metav1.ListOptions{TimeoutSeconds: ptr.To[int64](30)})
 	if err != nil {
 		klog.Errorf("list namespace error %v", err)
-		return 0
+		return nil
 	}
-	return len(namespaceList.Items)
+	names := make([]string, len(namespaceList.Items))
+	for i, ns := range namespaceList.Items {
+		names[i] = ns.Name
+	}
+	return names
 }
 
-func (c Cluster) getNodes(ctx context.Context, kubeClient kubernetes.Interface) []Node {
+// getNodes returns the statistics node data and the raw nodes.
+func (c Cluster) getNodes(ctx context.Context, kubeClient kubernetes.Interface) ([]Node, []corev1.Node) {
 	nodeList, err := kubeClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{TimeoutSeconds: ptr.To[int64](30)})
 	if err != nil {
 		klog.Errorf("get node list from cluster kube config error %v", err)
-		return nil
+		return nil, nil
 	}
 	// statistics node data
 	resNode := make([]Node, len(nodeList.Items))
@@ -151,9 +183,24 @@ func (c Cluster) getNodes(ctx context.Context, kubeClient kubernetes.Interface)
 			Kubelet:          node.Status.NodeInfo.KubeletVersion,
 			Os:               node.Status.NodeInfo.OperatingSystem,
 			OsImage:          node.Status.NodeInfo.OSImage,
+			Zone:             topologyLabel(node.Labels, corev1.LabelTopologyZone, corev1.LabelFailureDomainBetaZone),
+			Region:           topologyLabel(node.Labels, corev1.LabelTopologyRegion, corev1.LabelFailureDomainBetaRegion),
+			Conditions:       make(map[string]string),
+			Taints:           make([]NodeTaint, len(node.Spec.Taints)),
+			Capacity:         newNodeResources(node.Status.Capacity),
+			Allocatable:      newNodeResources(node.Status.Allocatable),
+		}
+		for _, condition := range node.Status.Conditions {
+			resNode[i].Conditions[string(condition.Type)] = string(condition.Status)
+			if condition.Type == corev1.NodeReady {
+				resNode[i].Ready = condition.Status == corev1.ConditionTrue
+			}
+		}
+		for j, taint := range node.Spec.Taints {
+			resNode[i].Taints[j] = NodeTaint{Key: taint.Key, Effect: string(taint.Effect)}
 		}
 	}
-	return resNode
+	return resNode, nodeList.Items
 }
 
 func (c Cluster) getVersion(ctx context.Context, client kubernetes.Interface, cluster clusterv1alpha1.Cluster) (string, string) {
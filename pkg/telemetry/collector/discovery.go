@@ -0,0 +1,73 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collector
+
+import (
+	"errors"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/discovery"
+	"k8s.io/klog/v2"
+)
+
+// ServedResources the resources served by the host cluster.
+type ServedResources struct {
+	// resources group/resource of served resources, including subresources. e.g. /services/proxy
+	resources sets.Set[string]
+	// unknownGroups the groups which failed to be discovered. their resources are assumed to be served.
+	unknownGroups sets.Set[string]
+}
+
+// DiscoverResources discovers the resources served by client.
+func DiscoverResources(client discovery.DiscoveryInterface) (*ServedResources, error) {
+	served := &ServedResources{resources: sets.New[string](), unknownGroups: sets.New[string]()}
+	_, lists, err := discovery.ServerGroupsAndResources(client)
+	if err != nil {
+		failed := &discovery.ErrGroupDiscoveryFailed{}
+		if !errors.As(err, &failed) {
+			return nil, err
+		}
+		klog.Warningf("discover resources error %v", err)
+		for gv := range failed.Groups {
+			served.unknownGroups.Insert(gv.Group)
+		}
+	}
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, r := range list.APIResources {
+			served.resources.Insert(gv.Group + "/" + r.Name)
+		}
+	}
+	return served, nil
+}
+
+// Missing returns the resources of requirements which are not served by the host cluster.
+// the resources read InClusters are not checked, since they are read in each cluster.
+func (s *ServedResources) Missing(requirements Requirements) []Resource {
+	var missing []Resource
+	for _, r := range requirements.HostResources() {
+		if s.unknownGroups.Has(r.Group) || s.resources.Has(r.Group+"/"+r.Resource) {
+			continue
+		}
+		missing = append(missing, r)
+	}
+	return missing
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collector
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	k8stesting "k8s.io/client-go/testing"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestServedResourcesMissing(t *testing.T) {
+	client := &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{Resources: []*metav1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{{Name: "namespaces"}, {Name: "services/proxy"}}},
+		{GroupVersion: "cluster.kubesphere.io/v1alpha1", APIResources: []metav1.APIResource{{Name: "clusters"}}},
+	}}}
+	served, err := DiscoverResources(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name         string
+		requirements Requirements
+		want         []Resource
+	}{
+		{
+			name: "served",
+			requirements: Requirements{Resources: []Resource{
+				clustersResource,
+				{Resource: "services/proxy", Verbs: []string{VerbGet}},
+			}},
+		},
+		{
+			name: "crd is missing",
+			requirements: Requirements{Resources: []Resource{
+				{Resource: "namespaces", Verbs: []string{VerbList}},
+				{Group: "kubesphere.io", Resource: "extensions", Verbs: []string{VerbList}},
+			}},
+			want: []Resource{{Group: "kubesphere.io", Resource: "extensions", Verbs: []string{VerbList}}},
+		},
+		{
+			name: "resources in clusters are not checked",
+			requirements: Requirements{Resources: []Resource{
+				{Group: "apps", Resource: "deployments", Verbs: []string{VerbList}, InClusters: true},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := served.Missing(tt.requirements); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Missing() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// opaqueCollector is a Collector which doesn't declare requirements.
+type opaqueCollector struct{}
+
+func (opaqueCollector) RecordKey() string { return "opaque" }
+
+func (opaqueCollector) Collect(context.Context, runtimeclient.Client) (interface{}, error) {
+	return nil, nil
+}
+
+func TestRequirementsOf(t *testing.T) {
+	tests := []struct {
+		name      string
+		collector Collector
+		wantScope Scope
+		wantCost  Cost
+	}{
+		{name: "declared", collector: &Workload{}, wantScope: ScopeMember, wantCost: CostHigh},
+		{name: "not a requirer", collector: opaqueCollector{}, wantScope: ScopeHost, wantCost: CostLow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RequirementsOf(tt.collector)
+			if got.Scope != tt.wantScope || got.Cost != tt.wantCost {
+				t.Errorf("RequirementsOf() scope = %s, cost = %s, want %s, %s", got.Scope, got.Cost, tt.wantScope, tt.wantCost)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collector
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	telemetryv1alpha1 "kubesphere.io/telemetry/pkg/apis/telemetry/v1alpha1"
+)
+
+// infer kubernetes distribution and cloud provider of cluster
+
+const (
+	FieldDistribution = "distribution"
+	FieldProvider     = "provider"
+
+	ConfidenceHigh   = "high"
+	ConfidenceMedium = "medium"
+	ConfidenceLow    = "low"
+
+	// DistributionKubernetes is used when no distribution is detected. e.g. installed by kubeadm or kubekey
+	DistributionKubernetes = "kubernetes"
+	ProviderUnknown        = "unknown"
+)
+
+type Evidence = telemetryv1alpha1.Evidence
+
+type distributionRule struct {
+	field string
+	value string
+	match string
+}
+
+var (
+	// providerIDRules prefix of node.spec.providerID set by cloud controller manager.
+	providerIDRules = []distributionRule{
+		{field: FieldProvider, value: "aws", match: "aws://"},
+		{field: FieldProvider, value: "gcp", match: "gce://"},
+		{field: FieldProvider, value: "azure", match: "azure://"},
+		{field: FieldProvider, value: "alibaba", match: "alicloud://"},
+		{field: FieldProvider, value: "tencent", match: "qcloud://"},
+		{field: FieldProvider, value: "huawei", match: "huaweicloud://"},
+		{field: FieldProvider, value: "baidu", match: "cce://"},
+		{field: FieldProvider, value: "ibm", match: "ibm://"},
+		{field: FieldProvider, value: "oracle", match: "oci://"},
+		{field: FieldProvider, value: "digitalocean", match: "digitalocean://"},
+		{field: FieldProvider, value: "hetzner", match: "hcloud://"},
+		{field: FieldProvider, value: "linode", match: "linode://"},
+		{field: FieldProvider, value: "openstack", match: "openstack://"},
+		{field: FieldProvider, value: "vsphere", match: "vsphere://"},
+		{field: FieldDistribution, value: "k3s", match: "k3s://"},
+		{field: FieldDistribution, value: "kind", match: "kind://"},
+	}
+	// aliyunProviderID the providerID of ack node is in the form of <region>.<instance-id>
+	aliyunProviderID = regexp.MustCompile(`^[a-z]{2}-[a-z0-9-]+\.i-[a-z0-9]+$`)
+
+	// kubeletVersionRules the version suffix of kubelet built by distributions.
+	kubeletVersionRules = []distributionRule{
+		{field: FieldDistribution, value: "eks", match: "-eks-"},
+		{field: FieldDistribution, value: "gke", match: "-gke."},
+		{field: FieldDistribution, value: "ack", match: "-aliyun."},
+		{field: FieldDistribution, value: "tke", match: "-tke."},
+		{field: FieldDistribution, value: "k3s", match: "+k3s"},
+		{field: FieldDistribution, value: "rke2", match: "+rke2"},
+		{field: FieldDistribution, value: "k0s", match: "+k0s"},
+		{field: FieldDistribution, value: "iks", match: "+iks"},
+		{field: FieldDistribution, value: "tkg", match: "+vmware"},
+	}
+
+	// labelRules the prefix of well-known node label keys.
+	labelRules = []distributionRule{
+		{field: FieldDistribution, value: "eks", match: "eks.amazonaws.com/"},
+		{field: FieldDistribution, value: "gke", match: "cloud.google.com/gke-"},
+		{field: FieldDistribution, value: "aks", match: "kubernetes.azure.com/"},
+		{field: FieldDistribution, value: "ack", match: "alibabacloud.com/nodepool-id"},
+		{field: FieldDistribution, value: "tke", match: "cloud.tencent.com/"},
+		{field: FieldDistribution, value: "cce", match: "cce.cloud.com/"},
+		{field: FieldDistribution, value: "openshift", match: "node.openshift.io/"},
+		{field: FieldDistribution, value: "microk8s", match: "microk8s.io/"},
+		{field: FieldProvider, value: "aws", match: "eks.amazonaws.com/"},
+		{field: FieldProvider, value: "gcp", match: "cloud.google.com/"},
+		{field: FieldProvider, value: "azure", match: "kubernetes.azure.com/"},
+		{field: FieldProvider, value: "alibaba", match: "alibabacloud.com/"},
+		{field: FieldProvider, value: "tencent", match: "cloud.tencent.com/"},
+		{field: FieldProvider, value: "huawei", match: "cce.cloud.com/"},
+	}
+	// instanceTypeRules the value of node.kubernetes.io/instance-type set by distributions without cloud provider.
+	instanceTypeRules = []distributionRule{
+		{field: FieldDistribution, value: "k3s", match: "k3s"},
+		{field: FieldDistribution, value: "rke2", match: "rke2"},
+	}
+
+	// namespaceRules the prefix of system namespaces created by distributions.
+	namespaceRules = []distributionRule{
+		{field: FieldDistribution, value: "openshift", match: "openshift-"},
+		{field: FieldDistribution, value: "gke", match: "gke-"},
+		{field: FieldDistribution, value: "rancher", match: "cattle-system"},
+		{field: FieldDistribution, value: "kubekey", match: "kubekey-system"},
+		{field: FieldDistribution, value: "microk8s", match: "ingress-microk8s"},
+	}
+)
+
+// confidenceWeight is used to choose the value with the most evidence.
+var confidenceWeight = map[string]int{
+	ConfidenceHigh:   3,
+	ConfidenceMedium: 2,
+	ConfidenceLow:    1,
+}
+
+// detectDistribution infers the distribution and provider from nodes and the namespace names of cluster.
+func detectDistribution(nodes []corev1.Node, namespaces []string) (string, string, []Evidence) {
+	var evidence []Evidence
+	seen := make(map[Evidence]bool)
+	add := func(rule distributionRule, source, confidence string) {
+		e := Evidence{Field: rule.field, Value: rule.value, Source: source, Match: rule.match, Confidence: confidence}
+		if !seen[e] {
+			seen[e] = true
+			evidence = append(evidence, e)
+		}
+	}
+
+	for _, node := range nodes {
+		providerID := node.Spec.ProviderID
+		for _, rule := range providerIDRules {
+			if strings.HasPrefix(providerID, rule.match) {
+				add(rule, "providerID", ConfidenceHigh)
+			}
+		}
+		if aliyunProviderID.MatchString(providerID) {
+			add(distributionRule{field: FieldProvider, value: "alibaba", match: aliyunProviderID.String()}, "providerID", ConfidenceHigh)
+		}
+		kubeletVersion := strings.ToLower(node.Status.NodeInfo.KubeletVersion)
+		for _, rule := range kubeletVersionRules {
+			if strings.Contains(kubeletVersion, rule.match) {
+				add(rule, "kubeletVersion", ConfidenceHigh)
+			}
+		}
+		for key := range node.Labels {
+			for _, rule := range labelRules {
+				if strings.HasPrefix(key, rule.match) {
+					add(rule, "label", ConfidenceMedium)
+				}
+			}
+		}
+		for _, rule := range instanceTypeRules {
+			if node.Labels[corev1.LabelInstanceTypeStable] == rule.match {
+				add(rule, "label", ConfidenceMedium)
+			}
+		}
+	}
+	for _, namespace := range namespaces {
+		for _, rule := range namespaceRules {
+			if strings.HasPrefix(namespace, rule.match) {
+				add(rule, "namespace", ConfidenceLow)
+			}
+		}
+	}
+
+	// the labels are iterated in random order, so that evidence is sorted by all the fields to be stable between runs.
+	sort.Slice(evidence, func(i, j int) bool {
+		a, b := evidence[i], evidence[j]
+		if a.Field != b.Field {
+			return a.Field < b.Field
+		}
+		if confidenceWeight[a.Confidence] != confidenceWeight[b.Confidence] {
+			return confidenceWeight[a.Confidence] > confidenceWeight[b.Confidence]
+		}
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		if a.Match != b.Match {
+			return a.Match < b.Match
+		}
+		return a.Value < b.Value
+	})
+	distribution := chooseValue(evidence, FieldDistribution)
+	if distribution == "" {
+		distribution = DistributionKubernetes
+	}
+	provider := chooseValue(evidence, FieldProvider)
+	if provider == "" {
+		provider = ProviderUnknown
+	}
+	return distribution, provider, evidence
+}
+
+// chooseValue returns the value of field with the highest total weight of evidence.
+func chooseValue(evidence []Evidence, field string) string {
+	scores := make(map[string]int)
+	var values []string
+	for _, e := range evidence {
+		if e.Field != field {
+			continue
+		}
+		if _, ok := scores[e.Value]; !ok {
+			values = append(values, e.Value)
+		}
+		scores[e.Value] += confidenceWeight[e.Confidence]
+	}
+	var res string
+	for _, v := range values {
+		if res == "" || scores[v] > scores[res] {
+			res = v
+		}
+	}
+	return res
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestDetectDistribution(t *testing.T) {
+	tests := []struct {
+		// fixture file in testdata/distribution
+		fixture      string
+		namespaces   []string
+		distribution string
+		provider     string
+	}{
+		{fixture: "eks.yaml", namespaces: []string{"kube-system", "amazon-cloudwatch"}, distribution: "eks", provider: "aws"},
+		{fixture: "ack.yaml", namespaces: []string{"kube-system", "arms-prom"}, distribution: "ack", provider: "alibaba"},
+		{fixture: "gke.yaml", namespaces: []string{"kube-system", "gke-managed-system", "gmp-system"}, distribution: "gke", provider: "gcp"},
+		{fixture: "aks.yaml", namespaces: []string{"kube-system", "gatekeeper-system"}, distribution: "aks", provider: "azure"},
+		{fixture: "tke.yaml", namespaces: []string{"kube-system"}, distribution: "tke", provider: "tencent"},
+		{fixture: "k3s.yaml", namespaces: []string{"kube-system"}, distribution: "k3s", provider: ProviderUnknown},
+		{fixture: "rke2-on-vsphere.yaml", namespaces: []string{"kube-system", "cattle-system"}, distribution: "rke2", provider: "vsphere"},
+		{fixture: "kind.yaml", namespaces: []string{"kube-system", "local-path-storage"}, distribution: "kind", provider: ProviderUnknown},
+		{fixture: "openshift.yaml", namespaces: []string{"openshift-apiserver", "openshift-etcd"}, distribution: "openshift", provider: ProviderUnknown},
+		{fixture: "kubekey.yaml", namespaces: []string{"kube-system", "kubesphere-system"}, distribution: DistributionKubernetes, provider: ProviderUnknown},
+		{fixture: "kubekey.yaml", namespaces: []string{"kube-system", "kubekey-system"}, distribution: "kubekey", provider: ProviderUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", "distribution", tt.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			nodeList := &corev1.NodeList{}
+			if err := yaml.Unmarshal(data, nodeList); err != nil {
+				t.Fatal(err)
+			}
+			distribution, provider, evidence := detectDistribution(nodeList.Items, tt.namespaces)
+			if distribution != tt.distribution {
+				t.Errorf("distribution = %s, want %s. evidence %v", distribution, tt.distribution, evidence)
+			}
+			if provider != tt.provider {
+				t.Errorf("provider = %s, want %s. evidence %v", provider, tt.provider, evidence)
+			}
+		})
+	}
+}
+
+func TestDetectDistributionStable(t *testing.T) {
+	// the labels match several distributions and providers with the same confidence
+	nodes := []corev1.Node{{}}
+	nodes[0].Labels = map[string]string{
+		"eks.amazonaws.com/nodegroup":        "a",
+		"cloud.google.com/gke-nodepool":      "b",
+		"kubernetes.azure.com/cluster":       "c",
+		"cloud.tencent.com/node-instance-id": "d",
+		"microk8s.io/cluster":                "true",
+	}
+	wantDistribution, wantProvider, want := detectDistribution(nodes, nil)
+	// the labels are iterated in random order
+	for i := 0; i < 20; i++ {
+		distribution, provider, evidence := detectDistribution(nodes, nil)
+		if !reflect.DeepEqual(evidence, want) {
+			t.Fatalf("evidence = %v, want %v", evidence, want)
+		}
+		if distribution != wantDistribution || provider != wantProvider {
+			t.Fatalf("distribution, provider = %s, %s, want %s, %s", distribution, provider, wantDistribution, wantProvider)
+		}
+	}
+}
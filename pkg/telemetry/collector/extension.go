@@ -19,40 +19,109 @@ package collector
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	corev1alpha1 "kubesphere.io/api/core/v1alpha1"
 	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	telemetryv1alpha1 "kubesphere.io/telemetry/pkg/apis/telemetry/v1alpha1"
 )
 
 func init() {
 	register(&Extension{})
 }
 
-type Extension struct {
-	Name    string `json:"name"`
-	Version string `json:"version"`
-	Ctime   string `json:"ctime"`
-}
+type Extension telemetryv1alpha1.Extension
+
+type (
+	ExtensionCondition    = telemetryv1alpha1.ExtensionCondition
+	ExtensionState        = telemetryv1alpha1.ExtensionState
+	ExtensionPlacement    = telemetryv1alpha1.ExtensionPlacement
+	ExtensionClusterState = telemetryv1alpha1.ExtensionClusterState
+)
 
 func (e Extension) RecordKey() string {
 	return "extension"
 }
 
+// Requirements implements Requirer.
+func (e Extension) Requirements() Requirements {
+	return Requirements{
+		Resources: []Resource{
+			{Group: "kubesphere.io", Resource: "installplans", Verbs: []string{VerbList}},
+			{Group: "kubesphere.io", Resource: "extensions", Verbs: []string{VerbList}},
+		},
+		Scope: ScopeHost,
+		Cost:  CostMedium,
+	}
+}
+
 func (e Extension) Collect(ctx context.Context, client runtimeclient.Client) (interface{}, error) {
 	subsList := &corev1alpha1.InstallPlanList{}
 	err := client.List(ctx, subsList)
 	if err != nil {
 		return nil, fmt.Errorf("get SubscriptionList error %v", err)
 	}
+	// repository of extension
+	extensionList := &corev1alpha1.ExtensionList{}
+	if err := client.List(ctx, extensionList); err != nil {
+		return nil, fmt.Errorf("get ExtensionList error %v", err)
+	}
+	repositories := make(map[string]string)
+	for _, ext := range extensionList.Items {
+		repositories[ext.Name] = ext.Labels[corev1alpha1.RepositoryReferenceLabel]
+	}
 	// statistic extension data
 	resData := make([]Extension, len(subsList.Items))
 	for i, s := range subsList.Items {
 		resData[i] = Extension{
-			Name:    s.Spec.Extension.Name,
-			Version: s.Spec.Extension.Version,
-			Ctime:   s.CreationTimestamp.Local().Format(time.RFC3339),
+			Name:         s.Spec.Extension.Name,
+			Version:      s.Spec.Extension.Version,
+			Ctime:        s.CreationTimestamp.Local().Format(time.RFC3339),
+			State:        s.Status.State,
+			Enabled:      s.Spec.Enabled,
+			Repository:   repositories[s.Spec.Extension.Name],
+			Conditions:   extensionConditions(s.Status.InstallationStatus),
+			StateHistory: make([]ExtensionState, len(s.Status.StateHistory)),
+			Clusters:     make([]ExtensionClusterState, 0, len(s.Status.ClusterSchedulingStatuses)),
+		}
+		for j, h := range s.Status.StateHistory {
+			resData[i].StateHistory[j] = ExtensionState{
+				State:              h.State,
+				LastTransitionTime: h.LastTransitionTime.UTC().Format(time.RFC3339),
+			}
+		}
+		if s.Spec.ClusterScheduling != nil && s.Spec.ClusterScheduling.Placement != nil {
+			resData[i].Placement = &ExtensionPlacement{
+				Clusters:        s.Spec.ClusterScheduling.Placement.Clusters,
+				ClusterSelector: s.Spec.ClusterScheduling.Placement.ClusterSelector != nil,
+			}
+		}
+		for cluster, status := range s.Status.ClusterSchedulingStatuses {
+			resData[i].Clusters = append(resData[i].Clusters, ExtensionClusterState{
+				Cluster:    cluster,
+				State:      status.State,
+				Conditions: extensionConditions(status),
+			})
 		}
+		sort.Slice(resData[i].Clusters, func(a, b int) bool {
+			return resData[i].Clusters[a].Cluster < resData[i].Clusters[b].Cluster
+		})
 	}
 	return resData, nil
 }
+
+// extensionConditions returns the conditions without message, which may contain sensitive data.
+func extensionConditions(status corev1alpha1.InstallationStatus) []ExtensionCondition {
+	conditions := make([]ExtensionCondition, len(status.Conditions))
+	for i, c := range status.Conditions {
+		conditions[i] = ExtensionCondition{
+			Type:               c.Type,
+			Status:             string(c.Status),
+			Reason:             c.Reason,
+			LastTransitionTime: c.LastTransitionTime.UTC().Format(time.RFC3339),
+		}
+	}
+	return conditions
+}
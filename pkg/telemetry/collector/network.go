@@ -0,0 +1,286 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+	clusterv1alpha1 "kubesphere.io/api/cluster/v1alpha1"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	telemetryv1alpha1 "kubesphere.io/telemetry/pkg/apis/telemetry/v1alpha1"
+)
+
+// collector network stack of each cluster
+
+const (
+	KubeProxyModeIPTables = "iptables"
+	KubeProxyModeReplaced = "replaced"
+	KubeProxyModeUnknown  = "unknown"
+
+	gatewayAPIGroup = "gateway.networking.k8s.io"
+)
+
+// cniDaemonSets the prefix of DaemonSet name deployed by each cni plugin.
+var cniDaemonSets = []struct {
+	prefix string
+	cni    string
+}{
+	{prefix: "calico-node", cni: "calico"},
+	{prefix: "canal", cni: "canal"},
+	{prefix: "cilium", cni: "cilium"},
+	{prefix: "kube-flannel", cni: "flannel"},
+	{prefix: "kube-ovn-cni", cni: "kube-ovn"},
+	{prefix: "weave-net", cni: "weave"},
+	{prefix: "antrea-agent", cni: "antrea"},
+	{prefix: "kube-router", cni: "kube-router"},
+	{prefix: "aws-node", cni: "aws-vpc-cni"},
+	{prefix: "terway", cni: "terway"},
+	{prefix: "ovnkube-node", cni: "ovn-kubernetes"},
+	{prefix: "kube-multus", cni: "multus"},
+	{prefix: "hybridnet", cni: "hybridnet"},
+}
+
+// cniConfigMaps the ConfigMap in kube-system created by each cni plugin.
+var cniConfigMaps = map[string]string{
+	"calico-config":    "calico",
+	"cilium-config":    "cilium",
+	"kube-flannel-cfg": "flannel",
+	"ovn-config":       "kube-ovn",
+	"antrea-config":    "antrea",
+}
+
+func init() {
+	register(&Network{})
+}
+
+type Network telemetryv1alpha1.Network
+
+type (
+	IngressClass = telemetryv1alpha1.IngressClass
+	NetworkCIDR  = telemetryv1alpha1.NetworkCIDR
+)
+
+func (n Network) RecordKey() string {
+	return "network"
+}
+
+// Requirements implements Requirer.
+func (n Network) Requirements() Requirements {
+	return Requirements{
+		Resources: []Resource{
+			clustersResource,
+			{Group: "apps", Resource: "daemonsets", Verbs: []string{VerbList}, InClusters: true},
+			{Resource: "configmaps", Verbs: []string{VerbList}, Namespace: metav1.NamespaceSystem, InClusters: true},
+			{Resource: "pods", Verbs: []string{VerbList}, Namespace: metav1.NamespaceSystem, InClusters: true},
+			{Group: "networking.k8s.io", Resource: "ingressclasses", Verbs: []string{VerbList}, InClusters: true},
+		},
+		Scope: ScopeMember,
+		Cost:  CostMedium,
+	}
+}
+
+func (n Network) Collect(ctx context.Context, client runtimeclient.Client) (interface{}, error) {
+	resNetwork := make([]Network, 0)
+	err := forEachCluster(ctx, client, func(cluster clusterv1alpha1.Cluster, kubeClient kubernetes.Interface) {
+		network, err := n.getNetwork(ctx, cluster.Name, kubeClient)
+		if err != nil {
+			klog.Errorf("skip network of cluster %s. %v", cluster.Name, err)
+			return
+		}
+		resNetwork = append(resNetwork, network)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resNetwork, nil
+}
+
+// getNetwork returns the network of cluster. the cluster is not reported rather than reported as empty
+// when any resource fails to list.
+func (n Network) getNetwork(ctx context.Context, clusterName string, kubeClient kubernetes.Interface) (Network, error) {
+	res := Network{
+		Cluster:            clusterName,
+		KubeProxyMode:      KubeProxyModeUnknown,
+		IngressControllers: make([]string, 0),
+		IngressClasses:     make([]IngressClass, 0),
+		GatewayAPI:         make([]string, 0),
+		ClusterCIDR:        make([]NetworkCIDR, 0),
+		ServiceCIDR:        make([]NetworkCIDR, 0),
+	}
+	opts := metav1.ListOptions{TimeoutSeconds: ptr.To[int64](30)}
+
+	cni := sets.New[string]()
+	kubeProxy := false
+	daemonSets, err := kubeClient.AppsV1().DaemonSets(metav1.NamespaceAll).List(ctx, opts)
+	if err != nil {
+		return res, fmt.Errorf("list daemonset from cluster %s error %v", clusterName, err)
+	}
+	for _, ds := range daemonSets.Items {
+		if ds.Namespace == metav1.NamespaceSystem && ds.Name == "kube-proxy" {
+			kubeProxy = true
+		}
+		for _, c := range cniDaemonSets {
+			if strings.HasPrefix(ds.Name, c.prefix) {
+				cni.Insert(c.cni)
+			}
+		}
+	}
+
+	configMaps, err := kubeClient.CoreV1().ConfigMaps(metav1.NamespaceSystem).List(ctx, opts)
+	if err != nil {
+		return res, fmt.Errorf("list configmap from cluster %s error %v", clusterName, err)
+	}
+	for _, cm := range configMaps.Items {
+		if c, ok := cniConfigMaps[cm.Name]; ok {
+			cni.Insert(c)
+		}
+		switch cm.Name {
+		case "kube-proxy":
+			if kubeProxy {
+				res.KubeProxyMode = kubeProxyMode(cm.Data["config.conf"])
+			}
+		case "cilium-config":
+			if !kubeProxy && (cm.Data["kube-proxy-replacement"] == "true" || cm.Data["kube-proxy-replacement"] == "strict") {
+				res.KubeProxyMode = KubeProxyModeReplaced
+			}
+		case "kubeadm-config":
+			res.ClusterCIDR, res.ServiceCIDR = kubeadmCIDR(cm.Data["ClusterConfiguration"])
+		}
+	}
+	res.CNI = sets.List(cni)
+	if len(res.ClusterCIDR) == 0 && len(res.ServiceCIDR) == 0 {
+		if res.ClusterCIDR, res.ServiceCIDR, err = n.controllerManagerCIDR(ctx, kubeClient); err != nil {
+			return res, fmt.Errorf("list kube-controller-manager pod from cluster %s error %v", clusterName, err)
+		}
+	}
+
+	ingressClasses, err := kubeClient.NetworkingV1().IngressClasses().List(ctx, opts)
+	if err != nil {
+		return res, fmt.Errorf("list ingressclass from cluster %s error %v", clusterName, err)
+	}
+	controllers := sets.New[string]()
+	for _, ic := range ingressClasses.Items {
+		res.IngressClasses = append(res.IngressClasses, IngressClass{
+			Name:       ic.Name,
+			Controller: ic.Spec.Controller,
+			Default:    ic.Annotations[networkingv1.AnnotationIsDefaultIngressClass] == "true",
+		})
+		controllers.Insert(ic.Spec.Controller)
+	}
+	res.IngressControllers = sets.List(controllers)
+
+	groups, err := kubeClient.Discovery().ServerGroups()
+	if err != nil {
+		return res, fmt.Errorf("discovery api groups from cluster %s error %v", clusterName, err)
+	}
+	for _, group := range groups.Groups {
+		if group.Name == gatewayAPIGroup {
+			for _, v := range group.Versions {
+				res.GatewayAPI = append(res.GatewayAPI, v.Version)
+			}
+		}
+	}
+	return res, nil
+}
+
+// controllerManagerCIDR returns the pod and service cidr in the args of kube-controller-manager static pod
+// when the cluster is not installed by kubeadm.
+func (n Network) controllerManagerCIDR(ctx context.Context, kubeClient kubernetes.Interface) ([]NetworkCIDR, []NetworkCIDR, error) {
+	clusterCIDR, serviceCIDR := make([]NetworkCIDR, 0), make([]NetworkCIDR, 0)
+	pods, err := kubeClient.CoreV1().Pods(metav1.NamespaceSystem).List(ctx, metav1.ListOptions{
+		LabelSelector:  "component=kube-controller-manager",
+		Limit:          1,
+		TimeoutSeconds: ptr.To[int64](30),
+	})
+	if err != nil {
+		return clusterCIDR, serviceCIDR, err
+	}
+	for _, pod := range pods.Items {
+		for _, container := range pod.Spec.Containers {
+			for _, arg := range append(container.Command, container.Args...) {
+				if v, ok := strings.CutPrefix(arg, "--cluster-cidr="); ok {
+					clusterCIDR = parseCIDRs(v)
+				}
+				if v, ok := strings.CutPrefix(arg, "--service-cluster-ip-range="); ok {
+					serviceCIDR = parseCIDRs(v)
+				}
+			}
+		}
+	}
+	return clusterCIDR, serviceCIDR, nil
+}
+
+// kubeProxyMode returns the mode in the KubeProxyConfiguration. iptables is default when mode is empty.
+func kubeProxyMode(config string) string {
+	kubeProxyConfig := struct {
+		Mode string `json:"mode"`
+	}{}
+	if err := yaml.Unmarshal([]byte(config), &kubeProxyConfig); err != nil {
+		klog.Errorf("unmarshal kube-proxy config error %v", err)
+		return KubeProxyModeUnknown
+	}
+	if kubeProxyConfig.Mode == "" {
+		return KubeProxyModeIPTables
+	}
+	return kubeProxyConfig.Mode
+}
+
+// kubeadmCIDR returns the pod and service cidr in the kubeadm ClusterConfiguration.
+func kubeadmCIDR(config string) ([]NetworkCIDR, []NetworkCIDR) {
+	clusterConfig := struct {
+		Networking struct {
+			PodSubnet     string `json:"podSubnet"`
+			ServiceSubnet string `json:"serviceSubnet"`
+		} `json:"networking"`
+	}{}
+	if err := yaml.Unmarshal([]byte(config), &clusterConfig); err != nil {
+		klog.Errorf("unmarshal kubeadm cluster configuration error %v", err)
+		return make([]NetworkCIDR, 0), make([]NetworkCIDR, 0)
+	}
+	return parseCIDRs(clusterConfig.Networking.PodSubnet), parseCIDRs(clusterConfig.Networking.ServiceSubnet)
+}
+
+// parseCIDRs parses comma separated cidr. e.g. 10.233.64.0/18,fd85:ee78:d8a6:8607::1:0000/112
+func parseCIDRs(s string) []NetworkCIDR {
+	res := make([]NetworkCIDR, 0)
+	for _, cidr := range strings.Split(s, ",") {
+		_, ipNet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			continue
+		}
+		ones, bits := ipNet.Mask.Size()
+		family := "IPv4"
+		if bits == net.IPv6len*8 {
+			family = "IPv6"
+		}
+		res = append(res, NetworkCIDR{Family: family, Prefix: ones})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Family < res[j].Family })
+	return res
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collector
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestGetNetwork(t *testing.T) {
+	objects := []runtime.Object{
+		&appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Name: "calico-node", Namespace: metav1.NamespaceSystem}},
+		&appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Name: "kube-proxy", Namespace: metav1.NamespaceSystem}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "kube-proxy", Namespace: metav1.NamespaceSystem}, Data: map[string]string{"config.conf": "mode: ipvs"}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "kubeadm-config", Namespace: metav1.NamespaceSystem}, Data: map[string]string{
+			"ClusterConfiguration": "networking:\n  podSubnet: 10.233.64.0/18\n  serviceSubnet: 10.233.0.0/18\n",
+		}},
+		&networkingv1.IngressClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "nginx", Annotations: map[string]string{networkingv1.AnnotationIsDefaultIngressClass: "true"}},
+			Spec:       networkingv1.IngressClassSpec{Controller: "k8s.io/ingress-nginx"},
+		},
+	}
+	tests := []struct {
+		name string
+		// failed the resource which fails to list
+		failed  string
+		want    Network
+		wantErr bool
+	}{
+		{
+			name: "listed",
+			want: Network{
+				Cluster:            "host",
+				CNI:                []string{"calico"},
+				KubeProxyMode:      "ipvs",
+				IngressControllers: []string{"k8s.io/ingress-nginx"},
+				IngressClasses:     []IngressClass{{Name: "nginx", Controller: "k8s.io/ingress-nginx", Default: true}},
+				GatewayAPI:         []string{},
+				ClusterCIDR:        []NetworkCIDR{{Family: "IPv4", Prefix: 18}},
+				ServiceCIDR:        []NetworkCIDR{{Family: "IPv4", Prefix: 18}},
+			},
+		},
+		{name: "daemonsets failed", failed: "daemonsets", wantErr: true},
+		{name: "configmaps failed", failed: "configmaps", wantErr: true},
+		{name: "ingressclasses failed", failed: "ingressclasses", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kubeClient := fake.NewSimpleClientset(objects...)
+			if tt.failed != "" {
+				kubeClient.PrependReactor("list", tt.failed, func(action k8stesting.Action) (bool, runtime.Object, error) {
+					return true, nil, errors.New("unavailable")
+				})
+			}
+			got, err := Network{}.getNetwork(context.Background(), "host", kubeClient)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getNetwork() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getNetwork() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
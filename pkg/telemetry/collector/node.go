@@ -0,0 +1,131 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collector
+
+import (
+	"strings"
+	"unicode"
+
+	corev1 "k8s.io/api/core/v1"
+
+	telemetryv1alpha1 "kubesphere.io/telemetry/pkg/apis/telemetry/v1alpha1"
+)
+
+// gpuResources extended resources of the well-known gpu device plugins which count the devices.
+var gpuResources = []string{
+	"nvidia.com/gpu",
+	"amd.com/gpu",
+	"gpu.intel.com/i915",
+	"gpu.intel.com/xe",
+}
+
+// gpuResourcePrefixes device plugins which name the resources by the model of device. e.g. huawei.com/Ascend910
+var gpuResourcePrefixes = []string{
+	"huawei.com/ascend",
+	"hygon.com/dcu",
+	"cambricon.com/mlu",
+}
+
+type (
+	NodeTaint     = telemetryv1alpha1.NodeTaint
+	NodeResources = telemetryv1alpha1.NodeResources
+	NodeSummary   = telemetryv1alpha1.NodeSummary
+)
+
+func newNodeResources(list corev1.ResourceList) NodeResources {
+	res := NodeResources{
+		CPU:              list.Cpu().MilliValue(),
+		Memory:           list.Memory().Value(),
+		EphemeralStorage: list.StorageEphemeral().Value(),
+		Pods:             list.Pods().Value(),
+	}
+	for name, quantity := range list {
+		if isGPUResource(name) {
+			if res.GPU == nil {
+				res.GPU = make(map[string]int64)
+			}
+			res.GPU[string(name)] = quantity.Value()
+		}
+	}
+	return res
+}
+
+// isGPUResource reports whether name counts gpu devices. the resources of the memory, cores or shared slices
+// of devices are not devices, e.g. nvidia.com/gpu.shared, nvidia.com/mig-1g.5gb, huawei.com/Ascend910-memory.
+func isGPUResource(name corev1.ResourceName) bool {
+	lower := strings.ToLower(string(name))
+	for _, resource := range gpuResources {
+		if lower == resource {
+			return true
+		}
+	}
+	for _, prefix := range gpuResourcePrefixes {
+		model, ok := strings.CutPrefix(lower, prefix)
+		if !ok || strings.Contains(model, "mem") || strings.Contains(model, "core") {
+			continue
+		}
+		if strings.IndexFunc(model, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func addNodeResources(r *NodeResources, other NodeResources) {
+	r.CPU += other.CPU
+	r.Memory += other.Memory
+	r.EphemeralStorage += other.EphemeralStorage
+	r.Pods += other.Pods
+	for name, value := range other.GPU {
+		if r.GPU == nil {
+			r.GPU = make(map[string]int64)
+		}
+		r.GPU[name] += value
+	}
+}
+
+// topologyLabel returns the value of the first label found in keys.
+func topologyLabel(labels map[string]string, keys ...string) string {
+	for _, key := range keys {
+		if v, ok := labels[key]; ok {
+			return v
+		}
+	}
+	return ""
+}
+
+func summarizeNodes(nodes []Node) NodeSummary {
+	summary := NodeSummary{
+		Total:  len(nodes),
+		Zone:   make(map[string]int),
+		Region: make(map[string]int),
+	}
+	for _, node := range nodes {
+		if node.Ready {
+			summary.Ready++
+		}
+		addNodeResources(&summary.Capacity, node.Capacity)
+		addNodeResources(&summary.Allocatable, node.Allocatable)
+		if node.Zone != "" {
+			summary.Zone[node.Zone]++
+		}
+		if node.Region != "" {
+			summary.Region[node.Region]++
+		}
+	}
+	return summary
+}
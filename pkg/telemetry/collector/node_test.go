@@ -0,0 +1,150 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collector
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestIsGPUResource(t *testing.T) {
+	tests := []struct {
+		name corev1.ResourceName
+		want bool
+	}{
+		{name: "nvidia.com/gpu", want: true},
+		{name: "amd.com/gpu", want: true},
+		{name: "gpu.intel.com/i915", want: true},
+		{name: "gpu.intel.com/xe", want: true},
+		{name: "huawei.com/Ascend910", want: true},
+		{name: "hygon.com/dcu", want: true},
+		{name: "cambricon.com/mlu", want: true},
+		{name: "cambricon.com/mlu370", want: true},
+		{name: "nvidia.com/gpu.shared", want: false},
+		{name: "nvidia.com/mig-1g.5gb", want: false},
+		{name: "nvidia.com/gpumem", want: false},
+		{name: "nvidia.com/gpucores", want: false},
+		{name: "gpu.intel.com/memory.max", want: false},
+		{name: "gpu.intel.com/millicores", want: false},
+		{name: "huawei.com/Ascend910-memory", want: false},
+		{name: "hygon.com/dcumem", want: false},
+		{name: "hygon.com/dcucores", want: false},
+		{name: "cambricon.com/mlu.smlu.vmemory", want: false},
+		{name: "example.com/gpu", want: false},
+		{name: corev1.ResourceCPU, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.name), func(t *testing.T) {
+			if got := isGPUResource(tt.name); got != tt.want {
+				t.Errorf("isGPUResource() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewNodeResources(t *testing.T) {
+	tests := []struct {
+		name string
+		list corev1.ResourceList
+		want NodeResources
+	}{
+		{
+			name: "empty",
+			list: corev1.ResourceList{},
+			want: NodeResources{},
+		},
+		{
+			name: "standard resources",
+			list: corev1.ResourceList{
+				corev1.ResourceCPU:              resource.MustParse("3500m"),
+				corev1.ResourceMemory:           resource.MustParse("8Gi"),
+				corev1.ResourceEphemeralStorage: resource.MustParse("100Gi"),
+				corev1.ResourcePods:             resource.MustParse("110"),
+			},
+			want: NodeResources{CPU: 3500, Memory: 8 << 30, EphemeralStorage: 100 << 30, Pods: 110},
+		},
+		{
+			name: "gpu devices only",
+			list: corev1.ResourceList{
+				corev1.ResourceCPU:      resource.MustParse("4"),
+				"nvidia.com/gpu":        resource.MustParse("2"),
+				"nvidia.com/gpu.shared": resource.MustParse("8"),
+				"nvidia.com/gpumem":     resource.MustParse("16384"),
+				"huawei.com/Ascend910":  resource.MustParse("1"),
+			},
+			want: NodeResources{CPU: 4000, GPU: map[string]int64{"nvidia.com/gpu": 2, "huawei.com/Ascend910": 1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newNodeResources(tt.list); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newNodeResources() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarizeNodes(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes []Node
+		want  NodeSummary
+	}{
+		{
+			name: "no nodes",
+			want: NodeSummary{Zone: map[string]int{}, Region: map[string]int{}},
+		},
+		{
+			name: "nodes",
+			nodes: []Node{
+				{
+					Name: "a", Ready: true, Zone: "z1", Region: "r1",
+					Capacity:    NodeResources{CPU: 4000, Memory: 8, Pods: 110, GPU: map[string]int64{"nvidia.com/gpu": 2}},
+					Allocatable: NodeResources{CPU: 3800, Memory: 7, Pods: 110, GPU: map[string]int64{"nvidia.com/gpu": 2}},
+				},
+				{
+					Name: "b", Ready: false, Zone: "z2", Region: "r1",
+					Capacity:    NodeResources{CPU: 2000, Memory: 4, Pods: 110},
+					Allocatable: NodeResources{CPU: 1900, Memory: 3, Pods: 110},
+				},
+				{
+					Name: "c", Ready: true, Zone: "z1",
+					Capacity:    NodeResources{CPU: 1000, GPU: map[string]int64{"nvidia.com/gpu": 1, "amd.com/gpu": 1}},
+					Allocatable: NodeResources{CPU: 1000, GPU: map[string]int64{"nvidia.com/gpu": 1}},
+				},
+			},
+			want: NodeSummary{
+				Total:       3,
+				Ready:       2,
+				Capacity:    NodeResources{CPU: 7000, Memory: 12, Pods: 220, GPU: map[string]int64{"nvidia.com/gpu": 3, "amd.com/gpu": 1}},
+				Allocatable: NodeResources{CPU: 6700, Memory: 10, Pods: 220, GPU: map[string]int64{"nvidia.com/gpu": 3}},
+				Zone:        map[string]int{"z1": 2, "z2": 1},
+				Region:      map[string]int{"r1": 2},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := summarizeNodes(tt.nodes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("summarizeNodes() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
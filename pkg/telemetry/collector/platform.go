@@ -18,25 +18,70 @@ package collector
 
 import (
 	"context"
+	"sort"
+	"strconv"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+	clusterv1alpha1 "kubesphere.io/api/cluster/v1alpha1"
 	iamv1beta1 "kubesphere.io/api/iam/v1beta1"
 	tenantv1beta1 "kubesphere.io/api/tenant/v1beta1"
 	runtimeClient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	telemetryv1alpha1 "kubesphere.io/telemetry/pkg/apis/telemetry/v1alpha1"
+)
+
+const (
+	workspaceLabel = "kubesphere.io/workspace"
+	// creatorAnnotation is set by ks-apiserver to the object created by user. built-in objects don't have it.
+	creatorAnnotation = "kubesphere.io/creator"
+
+	// UserStatePending the state of user which is not handled by controller yet.
+	UserStatePending = "Pending"
+	// IdentityProviderLocal the identity provider of the user which is created in kubesphere.
+	IdentityProviderLocal = "local"
+	// IdentityProviderExternal the identity provider of the user which logs in by a configured identity provider.
+	// the name of identity provider is chosen by the administrator, so it's not exported.
+	IdentityProviderExternal = "external"
+	// PlacementClusterSelector the key of workspaces placed by cluster selector in WorkspacePlacement.
+	PlacementClusterSelector = "clusterSelector"
 )
 
 func init() {
 	register(&Project{})
 }
 
-type Project struct {
-	Workspace int `json:"workspace"`
-	User      int `json:"user"`
-}
+type Project telemetryv1alpha1.Platform
+
+type Distribution = telemetryv1alpha1.Distribution
 
 func (p Project) RecordKey() string {
 	return "platform"
 }
 
+// Requirements implements Requirer.
+func (p Project) Requirements() Requirements {
+	return Requirements{
+		Resources: []Resource{
+			clustersResource,
+			{Group: "tenant.kubesphere.io", Resource: "workspaces", Verbs: []string{VerbList}},
+			{Group: "tenant.kubesphere.io", Resource: "workspacetemplates", Verbs: []string{VerbList}},
+			{Group: "iam.kubesphere.io", Resource: "users", Verbs: []string{VerbList}},
+			{Group: "iam.kubesphere.io", Resource: "globalrolebindings", Verbs: []string{VerbList}},
+			{Group: "iam.kubesphere.io", Resource: "workspacerolebindings", Verbs: []string{VerbList}},
+			{Group: "iam.kubesphere.io", Resource: "globalroles", Verbs: []string{VerbList}},
+			{Group: "iam.kubesphere.io", Resource: "workspaceroles", Verbs: []string{VerbList}},
+			{Group: "iam.kubesphere.io", Resource: "clusterroles", Verbs: []string{VerbList}},
+			{Group: "iam.kubesphere.io", Resource: "roles", Verbs: []string{VerbList}},
+			{Resource: "namespaces", Verbs: []string{VerbList}, InClusters: true},
+		},
+		Scope: ScopeMember,
+		Cost:  CostMedium,
+	}
+}
+
 func (p Project) Collect(ctx context.Context, client runtimeClient.Client) (interface{}, error) {
 	workspaceList := &tenantv1beta1.WorkspaceList{}
 	userList := &iamv1beta1.UserList{}
@@ -48,10 +93,100 @@ func (p Project) Collect(ctx context.Context, client runtimeClient.Client) (inte
 	p.Workspace = len(workspaceList.Items)
 
 	// counting the number of user
-	if err := client.List(context.Background(), userList); err != nil {
+	if err := client.List(ctx, userList); err != nil {
 		return nil, err
 	}
 	p.User = len(userList.Items)
+	p.UserState = make(map[string]int)
+	p.UserIdentityProvider = make(map[string]int)
+	for _, user := range userList.Items {
+		state := string(user.Status.State)
+		if state == "" {
+			state = UserStatePending
+		}
+		p.UserState[state]++
+		if user.Labels[iamv1beta1.IdentifyProviderLabel] == "" {
+			p.UserIdentityProvider[IdentityProviderLocal]++
+		} else {
+			p.UserIdentityProvider[IdentityProviderExternal]++
+		}
+	}
+
+	// counting the workspaces by the number of clusters they are placed in. the names of clusters are not exported.
+	workspaceTemplateList := &tenantv1beta1.WorkspaceTemplateList{}
+	if err := client.List(ctx, workspaceTemplateList); err != nil {
+		return nil, err
+	}
+	p.WorkspacePlacement = make(map[string]int)
+	for _, wt := range workspaceTemplateList.Items {
+		if wt.Spec.Placement.ClusterSelector != nil {
+			p.WorkspacePlacement[PlacementClusterSelector]++
+			continue
+		}
+		p.WorkspacePlacement[strconv.Itoa(len(wt.Spec.Placement.Clusters))]++
+	}
+
+	// counting the namespaces of each workspace in all clusters
+	namespaces := make(map[string]int, len(workspaceList.Items))
+	for _, workspace := range workspaceList.Items {
+		namespaces[workspace.Name] = 0
+	}
+	if err := forEachCluster(ctx, client, func(cluster clusterv1alpha1.Cluster, kubeClient kubernetes.Interface) {
+		namespaceList, err := kubeClient.CoreV1().Namespaces().List(ctx, metav1.ListOptions{LabelSelector: workspaceLabel, TimeoutSeconds: ptr.To[int64](30)})
+		if err != nil {
+			klog.Errorf("list namespace from cluster %s error %v", cluster.Name, err)
+			return
+		}
+		for _, ns := range namespaceList.Items {
+			namespaces[ns.Labels[workspaceLabel]]++
+		}
+	}); err != nil {
+		return nil, err
+	}
+	counts := make([]int, 0, len(namespaces))
+	for _, count := range namespaces {
+		counts = append(counts, count)
+	}
+	p.NamespacePerWorkspace = newDistribution(counts)
+
+	// counting role bindings and custom roles
+	globalRoleBindingList := &iamv1beta1.GlobalRoleBindingList{}
+	if err := client.List(ctx, globalRoleBindingList); err != nil {
+		return nil, err
+	}
+	p.GlobalRoleBinding = len(globalRoleBindingList.Items)
+	workspaceRoleBindingList := &iamv1beta1.WorkspaceRoleBindingList{}
+	if err := client.List(ctx, workspaceRoleBindingList); err != nil {
+		return nil, err
+	}
+	p.WorkspaceRoleBinding = len(workspaceRoleBindingList.Items)
+
+	p.CustomRole = make(map[string]int)
+	for _, kind := range []string{"GlobalRole", "WorkspaceRole", "ClusterRole", "Role"} {
+		// only metadata is needed
+		roleList := &metav1.PartialObjectMetadataList{}
+		roleList.SetGroupVersionKind(iamv1beta1.SchemeGroupVersion.WithKind(kind + "List"))
+		if err := client.List(ctx, roleList); err != nil {
+			return nil, err
+		}
+		for _, role := range roleList.Items {
+			if _, ok := role.Annotations[creatorAnnotation]; ok {
+				p.CustomRole[kind]++
+			}
+		}
+	}
 
 	return p, nil
 }
+
+func newDistribution(values []int) Distribution {
+	if len(values) == 0 {
+		return Distribution{}
+	}
+	sort.Ints(values)
+	return Distribution{
+		Min:    values[0],
+		Median: values[len(values)/2],
+		Max:    values[len(values)-1],
+	}
+}
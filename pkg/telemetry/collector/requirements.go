@@ -0,0 +1,165 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collector
+
+import (
+	"sort"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	VerbGet    = "get"
+	VerbList   = "list"
+	VerbCreate = "create"
+	VerbUpdate = "update"
+	VerbPatch  = "patch"
+	VerbDelete = "delete"
+)
+
+// Resource a kind of resource which is read by collector.
+type Resource struct {
+	// Group the api group of resource. it's empty for the core group.
+	Group string
+	// Resource the plural name of resource. e.g. nodes, services/proxy
+	Resource string
+	// Verbs the verbs on resource.
+	Verbs []string
+	// Namespace the resource is only read in the namespace. it's empty for all namespaces or cluster scope resource.
+	Namespace string
+	// ResourceNames the resource is only read by name.
+	ResourceNames []string
+	// InClusters the resource is read in each cluster by the kubeconfig of cluster rather than the client of telemetry.
+	InClusters bool
+}
+
+// Scope where collector reads data.
+type Scope string
+
+const (
+	// ScopeHost collector only reads the host cluster by the client of telemetry.
+	ScopeHost Scope = "Host"
+	// ScopeMember collector reads each cluster by the kubeconfig of cluster.
+	ScopeMember Scope = "Member"
+)
+
+// Cost how heavy the collection is for the apiservers.
+type Cost string
+
+const (
+	// CostLow collector reads a few objects.
+	CostLow Cost = "Low"
+	// CostMedium collector lists resources which grow slowly with the clusters. e.g. namespaces, extensions
+	CostMedium Cost = "Medium"
+	// CostHigh collector lists resources which grow with the workloads. e.g. pods, persistentvolumeclaims
+	CostHigh Cost = "High"
+)
+
+// Requirements what collector needs to collect data.
+type Requirements struct {
+	// Resources the resources which collector reads.
+	Resources []Resource
+	// Scope where collector reads data. it's ScopeMember when any resource is read InClusters if it's empty.
+	Scope Scope
+	// Cost of collection. it's CostLow if it's empty.
+	Cost Cost
+}
+
+// HostResources returns the resources which are read by the client of telemetry.
+func (r Requirements) HostResources() []Resource {
+	var resources []Resource
+	for _, resource := range r.Resources {
+		if !resource.InClusters {
+			resources = append(resources, resource)
+		}
+	}
+	return resources
+}
+
+// Requirer is an optional interface of Collector which declares the requirements of collector.
+// the rbac rules of telemetry are derived from it.
+type Requirer interface {
+	Requirements() Requirements
+}
+
+// RequirementsOf returns the requirements of c with the default scope and cost.
+// the resources are empty when c is not a Requirer.
+func RequirementsOf(c Collector) Requirements {
+	var requirements Requirements
+	if r, ok := c.(Requirer); ok {
+		requirements = r.Requirements()
+	}
+	if requirements.Scope == "" {
+		requirements.Scope = ScopeHost
+		if len(requirements.HostResources()) != len(requirements.Resources) {
+			requirements.Scope = ScopeMember
+		}
+	}
+	if requirements.Cost == "" {
+		requirements.Cost = CostLow
+	}
+	return requirements
+}
+
+// clustersResource is read by the collectors which collect data in each cluster.
+var clustersResource = Resource{Group: "cluster.kubesphere.io", Resource: "clusters", Verbs: []string{VerbList}}
+
+// PolicyRules returns the rbac rules to access resources. the rules of the same group, verbs and names are merged.
+// the namespace of resources is ignored.
+func PolicyRules(resources []Resource) []rbacv1.PolicyRule {
+	type ruleKey struct {
+		group, verbs, names string
+	}
+	merged := make(map[ruleKey]sets.Set[string])
+	for _, r := range resources {
+		verbs := sets.List(sets.New(r.Verbs...))
+		names := sets.List(sets.New(r.ResourceNames...))
+		key := ruleKey{group: r.Group, verbs: strings.Join(verbs, ","), names: strings.Join(names, ",")}
+		if merged[key] == nil {
+			merged[key] = sets.New[string]()
+		}
+		merged[key].Insert(r.Resource)
+	}
+	rules := make([]rbacv1.PolicyRule, 0, len(merged))
+	for key, resources := range merged {
+		rule := rbacv1.PolicyRule{
+			APIGroups: []string{key.group},
+			Resources: sets.List(resources),
+			Verbs:     strings.Split(key.verbs, ","),
+		}
+		if key.names != "" {
+			rule.ResourceNames = strings.Split(key.names, ",")
+		}
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		a, b := rules[i], rules[j]
+		if a.APIGroups[0] != b.APIGroups[0] {
+			return a.APIGroups[0] < b.APIGroups[0]
+		}
+		if x, y := strings.Join(a.Verbs, ","), strings.Join(b.Verbs, ","); x != y {
+			return x < y
+		}
+		if x, y := strings.Join(a.ResourceNames, ","), strings.Join(b.ResourceNames, ","); x != y {
+			return x < y
+		}
+		return strings.Join(a.Resources, ",") < strings.Join(b.Resources, ",")
+	})
+	return rules
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collector
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+	clusterv1alpha1 "kubesphere.io/api/cluster/v1alpha1"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	telemetryv1alpha1 "kubesphere.io/telemetry/pkg/apis/telemetry/v1alpha1"
+)
+
+// collector storage data of each cluster
+
+const (
+	annotationDefaultStorageClass     = "storageclass.kubernetes.io/is-default-class"
+	annotationBetaDefaultStorageClass = "storageclass.beta.kubernetes.io/is-default-class"
+	annotationBetaStorageClass        = "volume.beta.kubernetes.io/storage-class"
+)
+
+func init() {
+	register(&Storage{})
+}
+
+type Storage telemetryv1alpha1.Storage
+
+type StorageClass = telemetryv1alpha1.StorageClass
+
+func (s Storage) RecordKey() string {
+	return "storage"
+}
+
+// Requirements implements Requirer.
+func (s Storage) Requirements() Requirements {
+	return Requirements{
+		Resources: []Resource{
+			clustersResource,
+			{Group: "storage.k8s.io", Resource: "storageclasses", Verbs: []string{VerbList}, InClusters: true},
+			{Group: "storage.k8s.io", Resource: "csidrivers", Verbs: []string{VerbList}, InClusters: true},
+			{Resource: "persistentvolumes", Verbs: []string{VerbList}, InClusters: true},
+			{Resource: "persistentvolumeclaims", Verbs: []string{VerbList}, InClusters: true},
+		},
+		Scope: ScopeMember,
+		Cost:  CostHigh,
+	}
+}
+
+func (s Storage) Collect(ctx context.Context, client runtimeclient.Client) (interface{}, error) {
+	resStorage := make([]Storage, 0)
+	err := forEachCluster(ctx, client, func(cluster clusterv1alpha1.Cluster, kubeClient kubernetes.Interface) {
+		storage, err := s.getStorage(ctx, cluster.Name, kubeClient)
+		if err != nil {
+			klog.Errorf("skip storage of cluster %s. %v", cluster.Name, err)
+			return
+		}
+		resStorage = append(resStorage, storage)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resStorage, nil
+}
+
+// getStorage returns the storage of cluster. the cluster is not reported rather than reported as empty
+// when any resource fails to list.
+func (s Storage) getStorage(ctx context.Context, clusterName string, kubeClient kubernetes.Interface) (Storage, error) {
+	res := Storage{
+		Cluster:        clusterName,
+		StorageClasses: make([]StorageClass, 0),
+		CSIDrivers:     make([]string, 0),
+	}
+	opts := metav1.ListOptions{TimeoutSeconds: ptr.To[int64](30)}
+
+	// index of storage class in res.StorageClasses by name
+	classIndex := make(map[string]int)
+	storageClasses, err := kubeClient.StorageV1().StorageClasses().List(ctx, opts)
+	if err != nil {
+		return res, fmt.Errorf("list storageclass from cluster %s error %v", clusterName, err)
+	}
+	for _, sc := range storageClasses.Items {
+		storageClass := StorageClass{
+			Name:        sc.Name,
+			Provisioner: sc.Provisioner,
+			Default:     sc.Annotations[annotationDefaultStorageClass] == "true" || sc.Annotations[annotationBetaDefaultStorageClass] == "true",
+		}
+		if sc.ReclaimPolicy != nil {
+			storageClass.ReclaimPolicy = string(*sc.ReclaimPolicy)
+		}
+		if sc.VolumeBindingMode != nil {
+			storageClass.VolumeBindingMode = string(*sc.VolumeBindingMode)
+		}
+		classIndex[sc.Name] = len(res.StorageClasses)
+		res.StorageClasses = append(res.StorageClasses, storageClass)
+	}
+
+	pvs, err := kubeClient.CoreV1().PersistentVolumes().List(ctx, opts)
+	if err != nil {
+		return res, fmt.Errorf("list persistentvolume from cluster %s error %v", clusterName, err)
+	}
+	res.PersistentVolume = len(pvs.Items)
+	for _, pv := range pvs.Items {
+		if i, ok := classIndex[pv.Spec.StorageClassName]; ok {
+			res.StorageClasses[i].PersistentVolume++
+		}
+	}
+
+	pvcs, err := kubeClient.CoreV1().PersistentVolumeClaims(metav1.NamespaceAll).List(ctx, opts)
+	if err != nil {
+		return res, fmt.Errorf("list persistentvolumeclaim from cluster %s error %v", clusterName, err)
+	}
+	res.PersistentVolumeClaim = len(pvcs.Items)
+	for _, pvc := range pvcs.Items {
+		requested := pvc.Spec.Resources.Requests.Storage().Value()
+		res.RequestedStorage += requested
+		if i, ok := classIndex[pvcStorageClass(pvc)]; ok {
+			res.StorageClasses[i].PersistentVolumeClaim++
+			res.StorageClasses[i].RequestedStorage += requested
+		}
+	}
+
+	csiDrivers, err := kubeClient.StorageV1().CSIDrivers().List(ctx, opts)
+	if err != nil {
+		return res, fmt.Errorf("list csidriver from cluster %s error %v", clusterName, err)
+	}
+	for _, driver := range csiDrivers.Items {
+		res.CSIDrivers = append(res.CSIDrivers, driver.Name)
+	}
+	return res, nil
+}
+
+func pvcStorageClass(pvc corev1.PersistentVolumeClaim) string {
+	if pvc.Spec.StorageClassName != nil {
+		return *pvc.Spec.StorageClassName
+	}
+	return pvc.Annotations[annotationBetaStorageClass]
+}
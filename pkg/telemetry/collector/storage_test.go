@@ -0,0 +1,93 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collector
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/utils/ptr"
+)
+
+func TestGetStorage(t *testing.T) {
+	objects := []runtime.Object{
+		&storagev1.StorageClass{
+			ObjectMeta:  metav1.ObjectMeta{Name: "local", Annotations: map[string]string{annotationDefaultStorageClass: "true"}},
+			Provisioner: "openebs.io/local",
+		},
+		&storagev1.CSIDriver{ObjectMeta: metav1.ObjectMeta{Name: "csi.example.com"}},
+		&corev1.PersistentVolume{ObjectMeta: metav1.ObjectMeta{Name: "a"}, Spec: corev1.PersistentVolumeSpec{StorageClassName: "local"}},
+		&corev1.PersistentVolume{ObjectMeta: metav1.ObjectMeta{Name: "b"}},
+		&corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "default"},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				StorageClassName: ptr.To("local"),
+				Resources:        corev1.VolumeResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")}},
+			},
+		},
+	}
+	tests := []struct {
+		name string
+		// failed the resource which fails to list
+		failed  string
+		want    Storage
+		wantErr bool
+	}{
+		{
+			name: "listed",
+			want: Storage{
+				Cluster: "host",
+				StorageClasses: []StorageClass{{
+					Name: "local", Provisioner: "openebs.io/local", Default: true,
+					PersistentVolume: 1, PersistentVolumeClaim: 1, RequestedStorage: 1 << 30,
+				}},
+				PersistentVolume:      2,
+				PersistentVolumeClaim: 1,
+				RequestedStorage:      1 << 30,
+				CSIDrivers:            []string{"csi.example.com"},
+			},
+		},
+		{name: "storageclasses failed", failed: "storageclasses", wantErr: true},
+		{name: "persistentvolumeclaims failed", failed: "persistentvolumeclaims", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kubeClient := fake.NewSimpleClientset(objects...)
+			if tt.failed != "" {
+				kubeClient.PrependReactor("list", tt.failed, func(action k8stesting.Action) (bool, runtime.Object, error) {
+					return true, nil, errors.New("unavailable")
+				})
+			}
+			got, err := Storage{}.getStorage(context.Background(), "host", kubeClient)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getStorage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getStorage() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collector
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/pager"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+	clusterv1alpha1 "kubesphere.io/api/cluster/v1alpha1"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	telemetryv1alpha1 "kubesphere.io/telemetry/pkg/apis/telemetry/v1alpha1"
+)
+
+// collector workload data of each cluster
+
+func init() {
+	register(&Workload{})
+}
+
+type Workload telemetryv1alpha1.Workload
+
+func (w Workload) RecordKey() string {
+	return "workloads"
+}
+
+// Requirements implements Requirer.
+func (w Workload) Requirements() Requirements {
+	return Requirements{
+		Resources: []Resource{
+			clustersResource,
+			{Group: "apps", Resource: "deployments", Verbs: []string{VerbList}, InClusters: true},
+			{Group: "apps", Resource: "statefulsets", Verbs: []string{VerbList}, InClusters: true},
+			{Group: "apps", Resource: "daemonsets", Verbs: []string{VerbList}, InClusters: true},
+			{Group: "batch", Resource: "jobs", Verbs: []string{VerbList}, InClusters: true},
+			{Group: "batch", Resource: "cronjobs", Verbs: []string{VerbList}, InClusters: true},
+			{Resource: "services", Verbs: []string{VerbList}, InClusters: true},
+			{Resource: "pods", Verbs: []string{VerbList}, InClusters: true},
+		},
+		Scope: ScopeMember,
+		Cost:  CostHigh,
+	}
+}
+
+func (w Workload) Collect(ctx context.Context, client runtimeclient.Client) (interface{}, error) {
+	resWorkload := make([]Workload, 0)
+	err := forEachCluster(ctx, client, func(cluster clusterv1alpha1.Cluster, kubeClient kubernetes.Interface) {
+		workload, err := w.getWorkload(ctx, cluster.Name, kubeClient)
+		if err != nil {
+			klog.Errorf("skip workload of cluster %s. %v", cluster.Name, err)
+			return
+		}
+		resWorkload = append(resWorkload, workload)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resWorkload, nil
+}
+
+// getWorkload returns the workload of cluster. the cluster is not reported rather than reported as empty
+// when any resource fails to list.
+func (w Workload) getWorkload(ctx context.Context, clusterName string, kubeClient kubernetes.Interface) (Workload, error) {
+	res := Workload{
+		Cluster: clusterName,
+		Pod:     make(map[string]int),
+		Service: make(map[string]int),
+	}
+	opts := metav1.ListOptions{TimeoutSeconds: ptr.To[int64](30)}
+	deployments, err := kubeClient.AppsV1().Deployments(metav1.NamespaceAll).List(ctx, opts)
+	if err != nil {
+		return res, fmt.Errorf("list deployment from cluster %s error %v", clusterName, err)
+	}
+	res.Deployment = len(deployments.Items)
+	statefulSets, err := kubeClient.AppsV1().StatefulSets(metav1.NamespaceAll).List(ctx, opts)
+	if err != nil {
+		return res, fmt.Errorf("list statefulset from cluster %s error %v", clusterName, err)
+	}
+	res.StatefulSet = len(statefulSets.Items)
+	daemonSets, err := kubeClient.AppsV1().DaemonSets(metav1.NamespaceAll).List(ctx, opts)
+	if err != nil {
+		return res, fmt.Errorf("list daemonset from cluster %s error %v", clusterName, err)
+	}
+	res.DaemonSet = len(daemonSets.Items)
+	jobs, err := kubeClient.BatchV1().Jobs(metav1.NamespaceAll).List(ctx, opts)
+	if err != nil {
+		return res, fmt.Errorf("list job from cluster %s error %v", clusterName, err)
+	}
+	res.Job = len(jobs.Items)
+	cronJobs, err := kubeClient.BatchV1().CronJobs(metav1.NamespaceAll).List(ctx, opts)
+	if err != nil {
+		return res, fmt.Errorf("list cronjob from cluster %s error %v", clusterName, err)
+	}
+	res.CronJob = len(cronJobs.Items)
+	services, err := kubeClient.CoreV1().Services(metav1.NamespaceAll).List(ctx, opts)
+	if err != nil {
+		return res, fmt.Errorf("list service from cluster %s error %v", clusterName, err)
+	}
+	for _, svc := range services.Items {
+		res.Service[string(svc.Spec.Type)]++
+	}
+	// pods may be huge. list them by page.
+	podPager := pager.New(func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+		return kubeClient.CoreV1().Pods(metav1.NamespaceAll).List(ctx, opts)
+	})
+	if err := podPager.EachListItem(ctx, opts, func(obj runtime.Object) error {
+		res.Pod[string(obj.(*corev1.Pod).Status.Phase)]++
+		return nil
+	}); err != nil {
+		return res, fmt.Errorf("list pod from cluster %s error %v", clusterName, err)
+	}
+	return res, nil
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collector
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestGetWorkload(t *testing.T) {
+	objects := []runtime.Object{
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "default"}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "kube-system"}},
+		&appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "kube-system"}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "default"}, Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "default"}, Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeNodePort}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "default"}, Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "default"}, Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "c", Namespace: "default"}, Status: corev1.PodStatus{Phase: corev1.PodPending}},
+	}
+	tests := []struct {
+		name string
+		// failed the resource which fails to list
+		failed  string
+		want    Workload
+		wantErr bool
+	}{
+		{
+			name: "listed",
+			want: Workload{
+				Cluster:    "host",
+				Deployment: 2,
+				DaemonSet:  1,
+				Pod:        map[string]int{"Running": 2, "Pending": 1},
+				Service:    map[string]int{"ClusterIP": 1, "NodePort": 1},
+			},
+		},
+		{name: "deployments failed", failed: "deployments", wantErr: true},
+		{name: "pods failed", failed: "pods", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kubeClient := fake.NewSimpleClientset(objects...)
+			if tt.failed != "" {
+				kubeClient.PrependReactor("list", tt.failed, func(action k8stesting.Action) (bool, runtime.Object, error) {
+					return true, nil, errors.New("unavailable")
+				})
+			}
+			got, err := Workload{}.getWorkload(context.Background(), "host", kubeClient)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getWorkload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getWorkload() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	APIVersion = "telemetry.kubesphere.io/v1alpha1"
+	Kind       = "TelemetryConfiguration"
+
+	// ConfigMapKey is the key of the configuration file in the ConfigMap.
+	ConfigMapKey = "telemetry.yaml"
+
+	ENV_URL               = "TELEMETRY_URL"
+	ENV_CLOUD_ID          = "TELEMETRY_CLOUD_ID"
+	ENV_CLOUD_SECRET      = "TELEMETRY_CLOUD_SECRET"
+	ENV_HISTORY_RETENTION = "TELEMETRY_HISTORY_RETENTION"
+	ENV_INTERVAL          = "TELEMETRY_INTERVAL"
+
+	ENV_HISTORY_MAX_COUNT      = "TELEMETRY_HISTORY_MAX_COUNT"
+	ENV_HISTORY_MAX_SIZE       = "TELEMETRY_HISTORY_MAX_SIZE"
+	ENV_HISTORY_PRUNE_UNSYNCED = "TELEMETRY_HISTORY_PRUNE_UNSYNCED"
+
+	ENV_HISTORY_COMPACTION          = "TELEMETRY_HISTORY_COMPACTION"
+	ENV_HISTORY_COMPACTION_KEEP_ALL = "TELEMETRY_HISTORY_COMPACTION_KEEP_ALL"
+	ENV_HISTORY_COMPACTION_DAILY    = "TELEMETRY_HISTORY_COMPACTION_DAILY"
+	ENV_HISTORY_COMPACTION_WEEKLY   = "TELEMETRY_HISTORY_COMPACTION_WEEKLY"
+
+	DefaultHistoryRetention = 365 * 24 * time.Hour
+	// default durations of history compaction
+	DefaultCompactionKeepAll = 7 * 24 * time.Hour
+	DefaultCompactionDaily   = 30 * 24 * time.Hour
+	DefaultCompactionWeekly  = 90 * 24 * time.Hour
+	// DefaultNamespace is used when the namespace of a referenced object is omitted.
+	DefaultNamespace = "kubesphere-system"
+)
+
+// Config is the configuration file of telemetry.
+type Config struct {
+	metav1.TypeMeta `json:",inline"`
+	// URL the url for kubesphere cloud. save cluster data to local file when it's empty.
+	URL string `json:"url,omitempty"`
+	// CloudID the id for kubesphere cloud.
+	CloudID string `json:"cloudId,omitempty"`
+	// CloudSecret the namespace/name of the Secret which stores cloudId, token and client certificates for kubesphere cloud.
+	// cloudId in the Secret takes precedence over CloudID.
+	CloudSecret string `json:"cloudSecret,omitempty"`
+	// HistoryRetention how long the clusterInfo crd retention. valid when product is kse.
+	HistoryRetention metav1.Duration `json:"historyRetention,omitempty"`
+	// HistoryMaxCount the max number of clusterInfo crd. the oldest are pruned first. zero means no limit.
+	HistoryMaxCount int `json:"historyMaxCount,omitempty"`
+	// HistoryMaxSize the max total size of the status of clusterInfo crd stored in etcd. e.g. 100Mi. zero means no limit.
+	HistoryMaxSize resource.Quantity `json:"historyMaxSize,omitempty"`
+	// HistoryPruneUnsynced whether the clusterInfo crd which is not synced to kubesphere cloud can be pruned by the retention.
+	HistoryPruneUnsynced bool `json:"historyPruneUnsynced,omitempty"`
+	// HistoryCompaction down-samples the old clusterInfo crd.
+	HistoryCompaction Compaction `json:"historyCompaction,omitempty"`
+	// Interval between two collections. run once and exit when it's zero.
+	Interval metav1.Duration `json:"interval,omitempty"`
+}
+
+// Compaction keeps every clusterInfo crd collected in KeepAll, then the first and the last of each day until Daily,
+// of each week until Weekly and of each month after. the clusterInfo crd which is not synced is always kept.
+type Compaction struct {
+	// Enabled whether to compact the history.
+	Enabled bool `json:"enabled,omitempty"`
+	// KeepAll keep every clusterInfo crd collected in this duration.
+	KeepAll metav1.Duration `json:"keepAll,omitempty"`
+	// Daily keep the first and the last clusterInfo crd of each day collected in this duration.
+	Daily metav1.Duration `json:"daily,omitempty"`
+	// Weekly keep the first and the last clusterInfo crd of each week collected in this duration.
+	Weekly metav1.Duration `json:"weekly,omitempty"`
+}
+
+// New returns the default configuration.
+func New() *Config {
+	return &Config{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: APIVersion,
+			Kind:       Kind,
+		},
+		HistoryRetention: metav1.Duration{Duration: DefaultHistoryRetention},
+		HistoryCompaction: Compaction{
+			KeepAll: metav1.Duration{Duration: DefaultCompactionKeepAll},
+			Daily:   metav1.Duration{Duration: DefaultCompactionDaily},
+			Weekly:  metav1.Duration{Duration: DefaultCompactionWeekly},
+		},
+	}
+}
+
+// LoadFile merges the configuration file in path into c.
+func (c *Config) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := c.load(data); err != nil {
+		return fmt.Errorf("failed to load config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// LoadConfigMap merges the configuration file stored in ConfigMapKey of the ConfigMap into c.
+func (c *Config) LoadConfigMap(ctx context.Context, client kubernetes.Interface, namespace, name string) error {
+	cm, err := client.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	return c.loadConfigMap(cm)
+}
+
+func (c *Config) loadConfigMap(cm *corev1.ConfigMap) error {
+	data, ok := cm.Data[ConfigMapKey]
+	if !ok {
+		return fmt.Errorf("key %s not found in configmap %s/%s", ConfigMapKey, cm.Namespace, cm.Name)
+	}
+	if err := c.load([]byte(data)); err != nil {
+		return fmt.Errorf("failed to load configmap %s/%s: %w", cm.Namespace, cm.Name, err)
+	}
+	return nil
+}
+
+func (c *Config) load(data []byte) error {
+	// fields not present in data keep their current value.
+	return yaml.UnmarshalStrict(data, c)
+}
+
+// LoadEnv overrides c with the environment variables which are set.
+func (c *Config) LoadEnv() error {
+	if v, ok := os.LookupEnv(ENV_URL); ok {
+		c.URL = v
+	}
+	if v, ok := os.LookupEnv(ENV_CLOUD_ID); ok {
+		c.CloudID = v
+	}
+	if v, ok := os.LookupEnv(ENV_CLOUD_SECRET); ok {
+		c.CloudSecret = v
+	}
+	if v, ok := os.LookupEnv(ENV_HISTORY_MAX_COUNT); ok && v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid env %s: %w", ENV_HISTORY_MAX_COUNT, err)
+		}
+		c.HistoryMaxCount = n
+	}
+	if v, ok := os.LookupEnv(ENV_HISTORY_MAX_SIZE); ok && v != "" {
+		q, err := resource.ParseQuantity(v)
+		if err != nil {
+			return fmt.Errorf("invalid env %s: %w", ENV_HISTORY_MAX_SIZE, err)
+		}
+		c.HistoryMaxSize = q
+	}
+	for env, b := range map[string]*bool{
+		ENV_HISTORY_PRUNE_UNSYNCED: &c.HistoryPruneUnsynced,
+		ENV_HISTORY_COMPACTION:     &c.HistoryCompaction.Enabled,
+	} {
+		v, ok := os.LookupEnv(env)
+		if !ok || v == "" {
+			continue
+		}
+		value, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid env %s: %w", env, err)
+		}
+		*b = value
+	}
+	for env, d := range map[string]*metav1.Duration{
+		ENV_HISTORY_RETENTION:           &c.HistoryRetention,
+		ENV_INTERVAL:                    &c.Interval,
+		ENV_HISTORY_COMPACTION_KEEP_ALL: &c.HistoryCompaction.KeepAll,
+		ENV_HISTORY_COMPACTION_DAILY:    &c.HistoryCompaction.Daily,
+		ENV_HISTORY_COMPACTION_WEEKLY:   &c.HistoryCompaction.Weekly,
+	} {
+		v, ok := os.LookupEnv(env)
+		if !ok || v == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid env %s: %w", env, err)
+		}
+		// zero retention in env has always meant the default.
+		if env == ENV_HISTORY_RETENTION && duration == 0 {
+			continue
+		}
+		d.Duration = duration
+	}
+	return nil
+}
+
+// Validate checks c and returns the errors with the name of the invalid field.
+func (c *Config) Validate() error {
+	var errs field.ErrorList
+	if c.APIVersion != APIVersion {
+		errs = append(errs, field.NotSupported(field.NewPath("apiVersion"), c.APIVersion, []string{APIVersion}))
+	}
+	if c.Kind != Kind {
+		errs = append(errs, field.NotSupported(field.NewPath("kind"), c.Kind, []string{Kind}))
+	}
+	if c.URL != "" {
+		if u, err := url.Parse(c.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, field.Invalid(field.NewPath("url"), c.URL, "must be an absolute http or https url"))
+		}
+	}
+	if c.CloudSecret != "" {
+		if ref := ParseNamespacedName(c.CloudSecret); strings.Contains(ref.Name, "/") || ref.Name == "" || ref.Namespace == "" {
+			errs = append(errs, field.Invalid(field.NewPath("cloudSecret"), c.CloudSecret, "must be in the form of namespace/name"))
+		}
+	}
+	if c.HistoryRetention.Duration <= 0 {
+		errs = append(errs, field.Invalid(field.NewPath("historyRetention"), c.HistoryRetention.Duration.String(), "must be greater than 0"))
+	}
+	if c.HistoryMaxCount < 0 {
+		errs = append(errs, field.Invalid(field.NewPath("historyMaxCount"), c.HistoryMaxCount, "must not be negative"))
+	}
+	if c.HistoryMaxSize.Sign() < 0 {
+		errs = append(errs, field.Invalid(field.NewPath("historyMaxSize"), c.HistoryMaxSize.String(), "must not be negative"))
+	}
+	if c.HistoryCompaction.Enabled {
+		path := field.NewPath("historyCompaction")
+		if c.HistoryCompaction.KeepAll.Duration < 0 {
+			errs = append(errs, field.Invalid(path.Child("keepAll"), c.HistoryCompaction.KeepAll.Duration.String(), "must not be negative"))
+		}
+		if c.HistoryCompaction.Daily.Duration < c.HistoryCompaction.KeepAll.Duration {
+			errs = append(errs, field.Invalid(path.Child("daily"), c.HistoryCompaction.Daily.Duration.String(), "must not be less than keepAll"))
+		}
+		if c.HistoryCompaction.Weekly.Duration < c.HistoryCompaction.Daily.Duration {
+			errs = append(errs, field.Invalid(path.Child("weekly"), c.HistoryCompaction.Weekly.Duration.String(), "must not be less than daily"))
+		}
+	}
+	if c.Interval.Duration < 0 {
+		errs = append(errs, field.Invalid(field.NewPath("interval"), c.Interval.Duration.String(), "must not be negative"))
+	}
+	return errs.ToAggregate()
+}
+
+// ParseNamespacedName parses s in the form of namespace/name. DefaultNamespace is used when namespace is omitted.
+func ParseNamespacedName(s string) types.NamespacedName {
+	namespace, name, found := strings.Cut(s, "/")
+	if !found {
+		return types.NamespacedName{Namespace: DefaultNamespace, Name: s}
+	}
+	return types.NamespacedName{Namespace: namespace, Name: name}
+}
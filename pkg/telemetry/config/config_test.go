@@ -0,0 +1,243 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func writeFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), ConfigMapKey)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		check   func(t *testing.T, c *Config)
+		wantErr string
+	}{
+		{
+			name: "fields in file override defaults",
+			content: `apiVersion: telemetry.kubesphere.io/v1alpha1
+kind: TelemetryConfiguration
+url: https://kubesphere.cloud
+historyMaxCount: 10
+historyMaxSize: 1Gi
+historyCompaction:
+  enabled: true
+  daily: 240h
+`,
+			check: func(t *testing.T, c *Config) {
+				if c.URL != "https://kubesphere.cloud" || c.HistoryMaxCount != 10 || c.HistoryMaxSize.Cmp(resource.MustParse("1Gi")) != 0 {
+					t.Errorf("config = %+v", c)
+				}
+				if !c.HistoryCompaction.Enabled || c.HistoryCompaction.Daily.Duration != 240*time.Hour {
+					t.Errorf("historyCompaction = %+v", c.HistoryCompaction)
+				}
+			},
+		},
+		{
+			name:    "fields not in file keep defaults",
+			content: "apiVersion: telemetry.kubesphere.io/v1alpha1\nkind: TelemetryConfiguration\n",
+			check: func(t *testing.T, c *Config) {
+				if c.HistoryRetention.Duration != DefaultHistoryRetention || !c.HistoryMaxSize.IsZero() {
+					t.Errorf("config = %+v", c)
+				}
+				if c.HistoryCompaction.KeepAll.Duration != DefaultCompactionKeepAll {
+					t.Errorf("historyCompaction.keepAll = %s, want %s", c.HistoryCompaction.KeepAll.Duration, DefaultCompactionKeepAll)
+				}
+			},
+		},
+		{
+			name:    "unknown field",
+			content: "apiVersion: telemetry.kubesphere.io/v1alpha1\nkind: TelemetryConfiguration\nhistoryRetentoin: 1h\n",
+			wantErr: "historyRetentoin",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New()
+			err := c.LoadFile(writeFile(t, tt.content))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadFile() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, c)
+		})
+	}
+}
+
+func TestLoadEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		check   func(t *testing.T, c *Config)
+		wantErr string
+	}{
+		{
+			name: "env overrides",
+			env: map[string]string{
+				ENV_URL:                    "https://kubesphere.cloud",
+				ENV_HISTORY_RETENTION:      "720h",
+				ENV_HISTORY_MAX_COUNT:      "5",
+				ENV_HISTORY_MAX_SIZE:       "10Mi",
+				ENV_HISTORY_PRUNE_UNSYNCED: "true",
+				ENV_INTERVAL:               "24h",
+			},
+			check: func(t *testing.T, c *Config) {
+				if c.URL != "https://kubesphere.cloud" || c.HistoryRetention.Duration != 720*time.Hour || c.Interval.Duration != 24*time.Hour {
+					t.Errorf("config = %+v", c)
+				}
+				if c.HistoryMaxCount != 5 || c.HistoryMaxSize.Cmp(resource.MustParse("10Mi")) != 0 || !c.HistoryPruneUnsynced {
+					t.Errorf("history limits = %d, %s, %v", c.HistoryMaxCount, c.HistoryMaxSize.String(), c.HistoryPruneUnsynced)
+				}
+			},
+		},
+		{
+			name: "empty env is ignored",
+			env:  map[string]string{ENV_HISTORY_MAX_COUNT: "", ENV_INTERVAL: ""},
+			check: func(t *testing.T, c *Config) {
+				if c.HistoryMaxCount != 0 || c.Interval.Duration != 0 {
+					t.Errorf("config = %+v", c)
+				}
+			},
+		},
+		{
+			name: "zero retention keeps the default",
+			env:  map[string]string{ENV_HISTORY_RETENTION: "0"},
+			check: func(t *testing.T, c *Config) {
+				if c.HistoryRetention.Duration != DefaultHistoryRetention {
+					t.Errorf("historyRetention = %s, want %s", c.HistoryRetention.Duration, DefaultHistoryRetention)
+				}
+			},
+		},
+		{name: "invalid duration", env: map[string]string{ENV_INTERVAL: "1d"}, wantErr: ENV_INTERVAL},
+		{name: "invalid count", env: map[string]string{ENV_HISTORY_MAX_COUNT: "ten"}, wantErr: ENV_HISTORY_MAX_COUNT},
+		{name: "invalid size", env: map[string]string{ENV_HISTORY_MAX_SIZE: "1GB"}, wantErr: ENV_HISTORY_MAX_SIZE},
+		{name: "invalid bool", env: map[string]string{ENV_HISTORY_COMPACTION: "yes"}, wantErr: ENV_HISTORY_COMPACTION},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			c := New()
+			err := c.LoadEnv()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadEnv() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, c)
+		})
+	}
+}
+
+func TestPrecedence(t *testing.T) {
+	path := writeFile(t, `apiVersion: telemetry.kubesphere.io/v1alpha1
+kind: TelemetryConfiguration
+url: https://file.kubesphere.cloud
+cloudId: file
+historyMaxCount: 10
+`)
+	t.Setenv(ENV_URL, "https://env.kubesphere.cloud")
+	c := New()
+	if err := c.LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.LoadEnv(); err != nil {
+		t.Fatal(err)
+	}
+	// env > file > defaults
+	if c.URL != "https://env.kubesphere.cloud" {
+		t.Errorf("url = %s, want the value of env", c.URL)
+	}
+	if c.CloudID != "file" || c.HistoryMaxCount != 10 {
+		t.Errorf("cloudId = %s, historyMaxCount = %d, want the values of file", c.CloudID, c.HistoryMaxCount)
+	}
+	if c.HistoryRetention.Duration != DefaultHistoryRetention {
+		t.Errorf("historyRetention = %s, want the default", c.HistoryRetention.Duration)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		// wantField the invalid field. empty for valid config
+		wantField string
+	}{
+		{name: "defaults", modify: func(c *Config) {}},
+		{name: "kind", modify: func(c *Config) { c.Kind = "Config" }, wantField: "kind"},
+		{name: "relative url", modify: func(c *Config) { c.URL = "kubesphere.cloud" }, wantField: "url"},
+		{name: "cloudSecret without name", modify: func(c *Config) { c.CloudSecret = "kubesphere-system/" }, wantField: "cloudSecret"},
+		{name: "cloudSecret without namespace", modify: func(c *Config) { c.CloudSecret = "telemetry" }},
+		{name: "zero retention", modify: func(c *Config) { c.HistoryRetention.Duration = 0 }, wantField: "historyRetention"},
+		{name: "negative count", modify: func(c *Config) { c.HistoryMaxCount = -1 }, wantField: "historyMaxCount"},
+		{name: "negative size", modify: func(c *Config) { c.HistoryMaxSize = resource.MustParse("-1Mi") }, wantField: "historyMaxSize"},
+		{name: "negative interval", modify: func(c *Config) { c.Interval = metav1.Duration{Duration: -time.Hour} }, wantField: "interval"},
+		{
+			name: "compaction out of order",
+			modify: func(c *Config) {
+				c.HistoryCompaction.Enabled = true
+				c.HistoryCompaction.Daily.Duration = time.Hour
+			},
+			wantField: "historyCompaction.daily",
+		},
+		{
+			name:   "compaction out of order but disabled",
+			modify: func(c *Config) { c.HistoryCompaction.Daily.Duration = time.Hour },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New()
+			tt.modify(c)
+			err := c.Validate()
+			if tt.wantField == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantField+":") {
+				t.Errorf("Validate() error = %v, want invalid %s", err, tt.wantField)
+			}
+		})
+	}
+}
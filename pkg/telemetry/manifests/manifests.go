@@ -0,0 +1,326 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/yaml"
+
+	"kubesphere.io/telemetry/crds"
+	"kubesphere.io/telemetry/pkg/telemetry"
+	"kubesphere.io/telemetry/pkg/telemetry/collector"
+	telemetryconfig "kubesphere.io/telemetry/pkg/telemetry/config"
+	"kubesphere.io/telemetry/pkg/telemetry/report"
+)
+
+const (
+	DefaultName      = "telemetry"
+	DefaultImage     = "kubesphere/telemetry"
+	DefaultSchedule  = "0 0 * * *"
+	DefaultReplicas  = 1
+	defaultContainer = "telemetry"
+)
+
+// Options of the manifests to deploy telemetry.
+type Options struct {
+	// Name the name of ServiceAccount, rbac, ConfigMap and CronJob or Deployment.
+	Name string
+	// Namespace which telemetry is deployed in.
+	Namespace string
+	// Image of telemetry.
+	Image string
+	// Schedule of CronJob. it's used when the interval of Config is zero.
+	Schedule string
+	// Replicas of Deployment. it's used when the interval of Config is not zero.
+	// leader election is enabled when it's more than 1.
+	Replicas int32
+	// Config is stored in the ConfigMap.
+	Config telemetryconfig.Config
+	// Collectors which telemetry runs. the ClusterRole is derived from the resources they read.
+	Collectors []collector.Collector
+}
+
+// NewOptions returns the default options.
+func NewOptions() *Options {
+	return &Options{
+		Name:       DefaultName,
+		Namespace:  telemetryconfig.DefaultNamespace,
+		Image:      DefaultImage,
+		Schedule:   DefaultSchedule,
+		Replicas:   DefaultReplicas,
+		Config:     *telemetryconfig.New(),
+		Collectors: collector.Registered,
+	}
+}
+
+// leaderElect whether replicas elect a leader in long-running mode.
+func (o *Options) leaderElect() bool {
+	return o.Config.Interval.Duration != 0 && o.Replicas > 1
+}
+
+// Rules the rbac rules of telemetry.
+type Rules struct {
+	// ClusterRules the rules of ClusterRole.
+	ClusterRules []rbacv1.PolicyRule `json:"clusterRules"`
+	// NamespaceRules the rules of Role in each namespace.
+	NamespaceRules map[string][]rbacv1.PolicyRule `json:"namespaceRules"`
+}
+
+// RulesFor returns the rules to access resources by the client of telemetry.
+// the resources which are read in each cluster by the kubeconfig of cluster are excluded.
+func RulesFor(resources []collector.Resource) Rules {
+	var clusterResources []collector.Resource
+	namespaceResources := make(map[string][]collector.Resource)
+	for _, r := range resources {
+		switch {
+		case r.InClusters:
+		case r.Namespace == "":
+			clusterResources = append(clusterResources, r)
+		default:
+			namespaceResources[r.Namespace] = append(namespaceResources[r.Namespace], r)
+		}
+	}
+	rules := Rules{
+		ClusterRules:   collector.PolicyRules(clusterResources),
+		NamespaceRules: make(map[string][]rbacv1.PolicyRule, len(namespaceResources)),
+	}
+	for namespace, resources := range namespaceResources {
+		rules.NamespaceRules[namespace] = collector.PolicyRules(resources)
+	}
+	return rules
+}
+
+// CollectorResources returns the resources which collectors read.
+func CollectorResources(collectors []collector.Collector) []collector.Resource {
+	var resources []collector.Resource
+	for _, c := range collectors {
+		resources = append(resources, collector.RequirementsOf(c).Resources...)
+	}
+	return resources
+}
+
+// Resources returns the resources which telemetry accesses with the options.
+func (o *Options) Resources() []collector.Resource {
+	resources := append(CollectorResources(o.Collectors), telemetry.Resources()...)
+	if o.Config.URL != "" {
+		var secret *types.NamespacedName
+		if o.Config.CloudSecret != "" {
+			secret = ptr.To(telemetryconfig.ParseNamespacedName(o.Config.CloudSecret))
+		}
+		resources = append(resources, report.CloudResources(secret)...)
+	}
+	// the configuration file
+	resources = append(resources, collector.Resource{
+		Resource:      "configmaps",
+		Verbs:         []string{collector.VerbGet},
+		Namespace:     o.Namespace,
+		ResourceNames: []string{o.Name},
+	})
+	if o.leaderElect() {
+		resources = append(resources, collector.Resource{
+			Group:     "coordination.k8s.io",
+			Resource:  "leases",
+			Verbs:     []string{collector.VerbGet, collector.VerbCreate, collector.VerbUpdate},
+			Namespace: o.Namespace,
+		})
+	}
+	return resources
+}
+
+// Render returns the objects to deploy telemetry: the CRD, ServiceAccount, rbac, ConfigMap and CronJob or Deployment.
+func Render(o *Options) ([]runtime.Object, error) {
+	crd, err := CRD()
+	if err != nil {
+		return nil, err
+	}
+	objects := []runtime.Object{crd}
+	objects = append(objects, &corev1.ServiceAccount{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+		ObjectMeta: o.objectMeta(o.Namespace),
+	})
+	subjects := []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: o.Name, Namespace: o.Namespace}}
+	rules := RulesFor(o.Resources())
+	objects = append(objects,
+		&rbacv1.ClusterRole{
+			TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRole"},
+			ObjectMeta: o.objectMeta(""),
+			Rules:      rules.ClusterRules,
+		},
+		&rbacv1.ClusterRoleBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRoleBinding"},
+			ObjectMeta: o.objectMeta(""),
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: o.Name},
+			Subjects:   subjects,
+		})
+	namespaces := make([]string, 0, len(rules.NamespaceRules))
+	for namespace := range rules.NamespaceRules {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	for _, namespace := range namespaces {
+		objects = append(objects,
+			&rbacv1.Role{
+				TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "Role"},
+				ObjectMeta: o.objectMeta(namespace),
+				Rules:      rules.NamespaceRules[namespace],
+			},
+			&rbacv1.RoleBinding{
+				TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "RoleBinding"},
+				ObjectMeta: o.objectMeta(namespace),
+				RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: o.Name},
+				Subjects:   subjects,
+			})
+	}
+
+	config, err := yaml.Marshal(o.Config)
+	if err != nil {
+		return nil, err
+	}
+	objects = append(objects, &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: o.objectMeta(o.Namespace),
+		Data:       map[string]string{telemetryconfig.ConfigMapKey: string(config)},
+	})
+
+	podTemplate := o.podTemplate()
+	if o.Config.Interval.Duration == 0 { // run once by CronJob
+		podTemplate.Spec.RestartPolicy = corev1.RestartPolicyOnFailure
+		objects = append(objects, &batchv1.CronJob{
+			TypeMeta:   metav1.TypeMeta{APIVersion: batchv1.SchemeGroupVersion.String(), Kind: "CronJob"},
+			ObjectMeta: o.objectMeta(o.Namespace),
+			Spec: batchv1.CronJobSpec{
+				Schedule:                   o.Schedule,
+				ConcurrencyPolicy:          batchv1.ForbidConcurrent,
+				SuccessfulJobsHistoryLimit: ptr.To[int32](1),
+				FailedJobsHistoryLimit:     ptr.To[int32](1),
+				JobTemplate: batchv1.JobTemplateSpec{
+					Spec: batchv1.JobSpec{Template: podTemplate},
+				},
+			},
+		})
+		return objects, nil
+	}
+	// long-running mode
+	objects = append(objects, &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: appsv1.SchemeGroupVersion.String(), Kind: "Deployment"},
+		ObjectMeta: o.objectMeta(o.Namespace),
+		Spec: appsv1.DeploymentSpec{
+			Replicas: ptr.To(o.Replicas),
+			Selector: &metav1.LabelSelector{MatchLabels: o.labels()},
+			Template: podTemplate,
+		},
+	})
+	return objects, nil
+}
+
+func (o *Options) labels() map[string]string {
+	return map[string]string{"app.kubernetes.io/name": o.Name}
+}
+
+func (o *Options) objectMeta(namespace string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{Name: o.Name, Namespace: namespace, Labels: o.labels()}
+}
+
+func (o *Options) podTemplate() corev1.PodTemplateSpec {
+	args := []string{"--config-map", o.Namespace + "/" + o.Name}
+	if o.leaderElect() {
+		args = append(args, "--leader-elect", "--leader-election-name", o.Name)
+	}
+	return corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: o.labels()},
+		Spec: corev1.PodSpec{
+			ServiceAccountName: o.Name,
+			Containers: []corev1.Container{{
+				Name:  defaultContainer,
+				Image: o.Image,
+				Args:  args,
+				// the events of a run are recorded on the pod
+				Env: []corev1.EnvVar{
+					{Name: "POD_NAME", ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"}}},
+					{Name: "POD_NAMESPACE", ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.namespace"}}},
+				},
+				SecurityContext: &corev1.SecurityContext{
+					AllowPrivilegeEscalation: ptr.To(false),
+					Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+				},
+			}},
+		},
+	}
+}
+
+// CRD returns the CustomResourceDefinition of ClusterInfo.
+func CRD() (*unstructured.Unstructured, error) {
+	data, err := fs.ReadFile(crds.FS, CRDFile)
+	if err != nil {
+		return nil, err
+	}
+	crd := &unstructured.Unstructured{}
+	if err := yaml.Unmarshal(data, &crd.Object); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", CRDFile, err)
+	}
+	return crd, nil
+}
+
+// CRDFile the file of the CRD in crds.FS.
+const CRDFile = "telemetry.kubesphere.io_clusterinfoes.yaml"
+
+// Write writes objects to w as yaml documents.
+func Write(w io.Writer, objects []runtime.Object) error {
+	for i, object := range objects {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(object)
+		if err != nil {
+			return err
+		}
+		// the empty fields which are not set by the manifests
+		for _, path := range [][]string{
+			{"metadata", "creationTimestamp"},
+			{"spec", "template", "metadata", "creationTimestamp"},
+			{"spec", "jobTemplate", "metadata", "creationTimestamp"},
+			{"spec", "jobTemplate", "spec", "template", "metadata", "creationTimestamp"},
+			{"spec", "strategy"},
+			{"status"},
+		} {
+			unstructured.RemoveNestedField(content, path...)
+		}
+		data, err := yaml.Marshal(content)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			if _, err := io.WriteString(w, "---\n"); err != nil {
+				return err
+			}
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manifests
+
+import (
+	"bytes"
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/yaml"
+
+	"kubesphere.io/telemetry/crds"
+	"kubesphere.io/telemetry/pkg/telemetry"
+	"kubesphere.io/telemetry/pkg/telemetry/collector"
+	telemetryconfig "kubesphere.io/telemetry/pkg/telemetry/config"
+	"kubesphere.io/telemetry/pkg/telemetry/report"
+)
+
+var update = flag.Bool("update", false, "update the files of the helm chart which are generated from the code")
+
+// chartDir the helm chart which is kept in sync with Render.
+const chartDir = "../../../charts/telemetry"
+
+// chartRules the content of files/rules.yaml in the helm chart.
+func chartRules(t *testing.T) []byte {
+	data, err := yaml.Marshal(map[string]Rules{
+		"collectors": RulesFor(CollectorResources(collector.Registered)),
+		"cloud":      RulesFor(report.CloudResources(nil)),
+		"telemetry":  RulesFor(telemetry.Resources()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := "# generated by `go test ./pkg/telemetry/manifests -update`. DO NOT EDIT.\n"
+	return append([]byte(header), data...)
+}
+
+func TestChartInSync(t *testing.T) {
+	crd, err := fs.ReadFile(crds.FS, CRDFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{
+		filepath.Join("crds", CRDFile):       crd,
+		filepath.Join("files", "rules.yaml"): chartRules(t),
+	}
+	for name, want := range files {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(chartDir, name)
+			if *update {
+				if err := os.WriteFile(path, want, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s is out of sync. run `go test ./pkg/telemetry/manifests -update`", path)
+			}
+		})
+	}
+}
+
+func TestChartValues(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join(chartDir, "values.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := struct {
+		Image struct {
+			Repository string `json:"repository"`
+		} `json:"image"`
+		Schedule string                 `json:"schedule"`
+		Replicas int32                  `json:"replicas"`
+		Config   telemetryconfig.Config `json:"config"`
+	}{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		t.Fatal(err)
+	}
+	o := NewOptions()
+	if values.Image.Repository != o.Image {
+		t.Errorf("image.repository = %s, want %s", values.Image.Repository, o.Image)
+	}
+	if values.Schedule != o.Schedule {
+		t.Errorf("schedule = %s, want %s", values.Schedule, o.Schedule)
+	}
+	if values.Replicas != o.Replicas {
+		t.Errorf("replicas = %d, want %d", values.Replicas, o.Replicas)
+	}
+	if values.Config.HistoryRetention != o.Config.HistoryRetention {
+		t.Errorf("config.historyRetention = %s, want %s", values.Config.HistoryRetention.Duration, o.Config.HistoryRetention.Duration)
+	}
+	if values.Config.HistoryMaxSize.Cmp(o.Config.HistoryMaxSize) != 0 {
+		t.Errorf("config.historyMaxSize = %s, want %s", values.Config.HistoryMaxSize.String(), o.Config.HistoryMaxSize.String())
+	}
+	if values.Config.Interval != o.Config.Interval {
+		t.Errorf("config.interval = %s, want %s", values.Config.Interval.Duration, o.Config.Interval.Duration)
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+		replicas int32
+		// kind of the workload
+		kind        string
+		leaderElect bool
+		// local the data is saved locally rather than synced to kubesphere cloud
+		local bool
+	}{
+		{name: "cronjob", kind: "CronJob"},
+		{name: "cronjob saved locally", kind: "CronJob", local: true},
+		{name: "deployment", interval: 24 * time.Hour, replicas: 1, kind: "Deployment"},
+		{name: "deployment with leader election", interval: 24 * time.Hour, replicas: 2, kind: "Deployment", leaderElect: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := NewOptions()
+			if !tt.local {
+				o.Config.URL = "https://kubesphere.cloud"
+			}
+			o.Config.Interval.Duration = tt.interval
+			if tt.replicas != 0 {
+				o.Replicas = tt.replicas
+			}
+			objects, err := Render(o)
+			if err != nil {
+				t.Fatal(err)
+			}
+			kinds := make([]string, len(objects))
+			var args []string
+			var roles []*rbacv1.Role
+			var clusterRole *rbacv1.ClusterRole
+			for i, object := range objects {
+				kinds[i] = object.GetObjectKind().GroupVersionKind().Kind
+				switch object := object.(type) {
+				case *batchv1.CronJob:
+					args = object.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Args
+				case *appsv1.Deployment:
+					args = object.Spec.Template.Spec.Containers[0].Args
+				case *rbacv1.Role:
+					roles = append(roles, object)
+				case *rbacv1.ClusterRole:
+					clusterRole = object
+				}
+			}
+			for _, kind := range []string{"CustomResourceDefinition", "ServiceAccount", "ClusterRole", "ClusterRoleBinding", "Role", "RoleBinding", "ConfigMap", tt.kind} {
+				if !slices.Contains(kinds, kind) {
+					t.Errorf("%s is not rendered. got %v", kind, kinds)
+				}
+			}
+			if got := slices.Contains(args, "--leader-elect"); got != tt.leaderElect {
+				t.Errorf("--leader-elect in args = %v, want %v. args %v", got, tt.leaderElect, args)
+			}
+			// events are recorded in every mode
+			var events, clusterInfoes bool
+			if clusterRole != nil {
+				for _, rule := range clusterRole.Rules {
+					events = events || slices.Contains(rule.Resources, "events")
+					clusterInfoes = clusterInfoes || slices.Contains(rule.Resources, "clusterinfoes")
+				}
+			}
+			if !events {
+				t.Errorf("events are not granted in ClusterRole")
+			}
+			if clusterInfoes == tt.local {
+				t.Errorf("clusterinfoes in ClusterRole = %v, want %v", clusterInfoes, !tt.local)
+			}
+			var leases bool
+			for _, role := range roles {
+				for _, rule := range role.Rules {
+					leases = leases || slices.Contains(rule.Resources, "leases")
+				}
+			}
+			if leases != tt.leaderElect {
+				t.Errorf("leases in roles = %v, want %v", leases, tt.leaderElect)
+			}
+			var out bytes.Buffer
+			if err := Write(&out, objects); err != nil {
+				t.Fatal(err)
+			}
+			if n := bytes.Count(out.Bytes(), []byte("\n---\n")) + 1; n != len(objects) {
+				t.Errorf("%d documents are written, want %d", n, len(objects))
+			}
+		})
+	}
+}
+
+func TestChartLongRunning(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join(chartDir, "templates", "_helpers.tpl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the pattern of the zero interval in telemetry.longRunning
+	match := regexp.MustCompile("regexMatch `([^`]+)` \\(toString .Values.config.interval\\)").FindSubmatch(data)
+	if match == nil {
+		t.Fatal("the pattern of the zero interval is not found in telemetry.longRunning")
+	}
+	zero := regexp.MustCompile(string(match[1]))
+	for _, interval := range []string{"0", "0s", "0m", "0h", "0h0m0s", "0.0s", "-0s", "1h", "30m", "0h30m", "0.5s", "100ms", "24h"} {
+		d, err := time.ParseDuration(interval)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := !zero.MatchString(interval); got != (d != 0) {
+			t.Errorf("long-running of interval %s = %v, want %v", interval, got, d != 0)
+		}
+	}
+	if !zero.MatchString("") {
+		t.Error("empty interval is long-running")
+	}
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preflight
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+
+	"kubesphere.io/telemetry/pkg/telemetry/collector"
+)
+
+// Requirement a permission which telemetry needs.
+type Requirement struct {
+	collector.Resource
+	// Users who need the permission. e.g. the record key of collector
+	Users []string
+}
+
+// Result of checking a Requirement.
+type Result struct {
+	// Client which accesses the resource. e.g. telemetry, cluster/host
+	Client string
+	Requirement
+	// Missing the verbs which are not allowed.
+	Missing []string
+	// Err the error of checking.
+	Err error
+}
+
+// Passed reports whether the permission is granted.
+func (r Result) Passed() bool {
+	return r.Err == nil && len(r.Missing) == 0
+}
+
+// Requirements merges resources of each user to requirements.
+// the resources read by the client of telemetry are returned in host, the resources read in each cluster in clusters.
+func Requirements(resources map[string][]collector.Resource) (host []Requirement, clusters []Requirement) {
+	type key struct {
+		inClusters                        bool
+		group, resource, namespace, names string
+	}
+	merged := make(map[key]*Requirement)
+	var keys []key
+	for user, rs := range resources {
+		for _, r := range rs {
+			k := key{
+				inClusters: r.InClusters,
+				group:      r.Group,
+				resource:   r.Resource,
+				namespace:  r.Namespace,
+				names:      strings.Join(sets.List(sets.New(r.ResourceNames...)), ","),
+			}
+			req, ok := merged[k]
+			if !ok {
+				req = &Requirement{Resource: r}
+				req.Verbs = nil
+				merged[k] = req
+				keys = append(keys, k)
+			}
+			req.Verbs = sets.List(sets.New(req.Verbs...).Insert(r.Verbs...))
+			req.Users = sets.List(sets.New(req.Users...).Insert(user))
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.group != b.group {
+			return a.group < b.group
+		}
+		if a.resource != b.resource {
+			return a.resource < b.resource
+		}
+		if a.namespace != b.namespace {
+			return a.namespace < b.namespace
+		}
+		return a.names < b.names
+	})
+	for _, k := range keys {
+		if k.inClusters {
+			clusters = append(clusters, *merged[k])
+		} else {
+			host = append(host, *merged[k])
+		}
+	}
+	return host, clusters
+}
+
+// Check checks requirements by SelfSubjectAccessReview with kubeClient. client is the name of kubeClient in the results.
+func Check(ctx context.Context, client string, kubeClient kubernetes.Interface, requirements []Requirement) []Result {
+	results := make([]Result, 0, len(requirements))
+	for _, req := range requirements {
+		result := Result{Client: client, Requirement: req}
+		resource, subresource, _ := strings.Cut(req.Resource.Resource, "/")
+		names := req.ResourceNames
+		if len(names) == 0 {
+			names = []string{""}
+		}
+		for _, verb := range req.Verbs {
+			allowed := true
+			for _, name := range names {
+				review := &authorizationv1.SelfSubjectAccessReview{
+					Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+						ResourceAttributes: &authorizationv1.ResourceAttributes{
+							Namespace:   req.Namespace,
+							Verb:        verb,
+							Group:       req.Group,
+							Resource:    resource,
+							Subresource: subresource,
+							Name:        name,
+						},
+					},
+				}
+				review, err := kubeClient.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+				if err != nil {
+					result.Err = err
+					break
+				}
+				allowed = allowed && review.Status.Allowed
+			}
+			if result.Err != nil {
+				break
+			}
+			if !allowed {
+				result.Missing = append(result.Missing, verb)
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
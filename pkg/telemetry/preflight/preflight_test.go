@@ -0,0 +1,85 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preflight
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"kubesphere.io/telemetry/pkg/telemetry/collector"
+)
+
+func TestRequirements(t *testing.T) {
+	host, clusters := Requirements(map[string][]collector.Resource{
+		"a": {
+			{Group: "apps", Resource: "deployments", Verbs: []string{"list"}, InClusters: true},
+			{Resource: "configmaps", Verbs: []string{"get"}, Namespace: "kube-system"},
+		},
+		"b": {
+			{Group: "apps", Resource: "deployments", Verbs: []string{"get", "list"}, InClusters: true},
+			{Group: "apps", Resource: "deployments", Verbs: []string{"list"}},
+		},
+	})
+	wantHost := []Requirement{
+		{Resource: collector.Resource{Resource: "configmaps", Verbs: []string{"get"}, Namespace: "kube-system"}, Users: []string{"a"}},
+		{Resource: collector.Resource{Group: "apps", Resource: "deployments", Verbs: []string{"list"}}, Users: []string{"b"}},
+	}
+	wantClusters := []Requirement{
+		{Resource: collector.Resource{Group: "apps", Resource: "deployments", Verbs: []string{"get", "list"}, InClusters: true}, Users: []string{"a", "b"}},
+	}
+	if !reflect.DeepEqual(host, wantHost) {
+		t.Errorf("host = %+v, want %+v", host, wantHost)
+	}
+	if !reflect.DeepEqual(clusters, wantClusters) {
+		t.Errorf("clusters = %+v, want %+v", clusters, wantClusters)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+	var reviewed []authorizationv1.ResourceAttributes
+	kubeClient.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		attributes := *review.Spec.ResourceAttributes
+		reviewed = append(reviewed, attributes)
+		// only allow get
+		review.Status.Allowed = attributes.Verb == "get"
+		return true, review, nil
+	})
+	results := Check(context.Background(), "telemetry", kubeClient, []Requirement{
+		{Resource: collector.Resource{Resource: "services/proxy", Verbs: []string{"get"}, Namespace: "kubesphere-system", ResourceNames: []string{"a", "b"}}},
+		{Resource: collector.Resource{Group: "apps", Resource: "deployments", Verbs: []string{"get", "list", "delete"}}},
+	})
+	if len(results) != 2 {
+		t.Fatalf("len(results) = %d, want 2", len(results))
+	}
+	if !results[0].Passed() {
+		t.Errorf("results[0] = %+v, want passed", results[0])
+	}
+	if want := []string{"list", "delete"}; !reflect.DeepEqual(results[1].Missing, want) {
+		t.Errorf("results[1].Missing = %v, want %v", results[1].Missing, want)
+	}
+	if want := (authorizationv1.ResourceAttributes{Namespace: "kubesphere-system", Verb: "get", Resource: "services", Subresource: "proxy", Name: "b"}); reviewed[1] != want {
+		t.Errorf("reviewed[1] = %+v, want %+v", reviewed[1], want)
+	}
+}
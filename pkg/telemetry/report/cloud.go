@@ -3,194 +3,637 @@ package report
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"reflect"
+	"sort"
 	"strings"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/discovery"
 	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
 	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
-)
 
-var (
-	CRDGroupVersionKind     = schema.GroupVersionKind{Group: "telemetry.kubesphere.io", Version: "v1alpha1", Kind: "ClusterInfo"}
-	CRDListGroupVersionKind = schema.GroupVersionKind{Group: "telemetry.kubesphere.io", Version: "v1alpha1", Kind: "ClusterInfoList"}
+	telemetryv1alpha1 "kubesphere.io/telemetry/pkg/apis/telemetry/v1alpha1"
+	"kubesphere.io/telemetry/pkg/telemetry/collector"
+	"kubesphere.io/telemetry/pkg/telemetry/snapshot"
 )
 
 const (
-	CRDKind     = "ClusterInfo"
-	CRDResource = "clusterinfoes"
-
 	ProductKSE = "kse"
 	ProductKS  = "ks"
 
 	defaultTelemetryEndpoint = "/apis/telemetry/v1/clusterinfos?cluster_id=${cluster_id}"
+
+	// reasons of the conditions in ClusterInfo
+	reasonDataCollected  = "DataCollected"
+	reasonWaitingForSync = "WaitingForSync"
+	reasonSyncSucceeded  = "SyncSucceeded"
+	reasonRequestFailed  = "RequestFailed"
+	// reasonPermanentFailure the data is rejected by ksCloud too many times and won't be synced again.
+	reasonPermanentFailure = "PermanentFailure"
+	// reasonSyncSkipped the data is not synced since clusterId is empty.
+	reasonSyncSkipped = "SyncSkipped"
+
+	// reasons of the events
+	eventReasonCollected  = "Collected"
+	eventReasonSynced     = "Synced"
+	eventReasonSyncFailed = "SyncFailed"
+	eventReasonExpired    = "Expired"
+	// eventReasonUnsyncedKept the unsynced ClusterInfo exceeding the history limits are kept.
+	eventReasonUnsyncedKept  = "UnsyncedKept"
+	eventReasonCompacted     = "Compacted"
+	eventReasonCompactFailed = "CompactFailed"
+	eventReasonDeleteFailed  = "DeleteFailed"
+
+	// syncBackoff the delay before retrying to sync a ClusterInfo after the first failure. it doubles after each failure.
+	syncBackoff = 5 * time.Minute
+	// maxSyncBackoff the max delay before retrying to sync a ClusterInfo.
+	maxSyncBackoff = 24 * time.Hour
+	// maxSyncClientErrors a ClusterInfo is permanently failed after ksCloud rejects it with a 4xx response for so many times.
+	maxSyncClientErrors = 3
+
+	// maxNameSuffix the max suffix of the name when ClusterInfo with the same name exists. e.g. 20240501000000-9
+	// the data is not saved when all the names are taken by different data.
+	maxNameSuffix = 9
 )
 
-func NewCloudReport(cloudURL string, cloudID string, historyRetention time.Duration, config *restclient.Config) (Report, error) {
+// errEmptyClusterID the data has no host cluster, so that ksCloud can't identify it.
+var errEmptyClusterID = errors.New("clusterId is empty")
+
+// CloudOption is a configuration option supplied to NewCloudReport.
+type CloudOption func(*cloudReport)
+
+// WithHistoryLimits prune the oldest ClusterInfo when the number or the total size of ClusterInfo exceeds the limits.
+// zero means no limit.
+func WithHistoryLimits(maxCount int, maxSize int64) CloudOption {
+	return func(k *cloudReport) {
+		k.retention.MaxCount = maxCount
+		k.retention.MaxSize = maxSize
+	}
+}
+
+// WithCompaction down-sample the old ClusterInfo by policy before pruning them by the retention.
+func WithCompaction(policy CompactionPolicy) CloudOption {
+	return func(k *cloudReport) {
+		k.compaction = &policy
+	}
+}
+
+// WithPruneUnsynced set whether the ClusterInfo which is not synced to ksCloud can be pruned.
+func WithPruneUnsynced(pruneUnsynced bool) CloudOption {
+	return func(k *cloudReport) {
+		k.retention.PruneUnsynced = pruneUnsynced
+	}
+}
+
+// WithEventRecorder set the recorder to record events on ClusterInfo.
+// the events which are not related to a ClusterInfo are recorded on object. e.g. the pod which runs telemetry.
+func WithEventRecorder(recorder record.EventRecorder, object runtime.Object) CloudOption {
+	return func(k *cloudReport) {
+		k.recorder = recorder
+		k.object = object
+	}
+}
+
+func NewCloudReport(cloudURL string, cloudID string, historyRetention time.Duration, config *restclient.Config, opts ...CloudOption) (Report, error) {
 	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
 	if err != nil {
 		return nil, err
 	}
-	client, err := runtimeclient.New(config, runtimeclient.Options{})
+	client, err := runtimeclient.New(config, runtimeclient.Options{Scheme: collector.Schema})
 	if err != nil {
 		return nil, err
 	}
-	return &cloudReport{
-		cloudURL:         cloudURL,
-		cloudID:          cloudID,
-		historyRetention: historyRetention,
-		client:           client,
-		discoveryClient:  discoveryClient,
-	}, nil
+	k := &cloudReport{
+		cloudURL:        cloudURL,
+		cloudID:         cloudID,
+		retention:       RetentionPolicy{MaxAge: historyRetention},
+		client:          client,
+		discoveryClient: discoveryClient,
+	}
+	for _, o := range opts {
+		o(k)
+	}
+	return k, nil
+}
+
+// CloudResources returns the resources which the cloud report accesses.
+// the Secret of credentials is read when credentialsSecret is not nil, see WithCredentialsSecret.
+func CloudResources(credentialsSecret *types.NamespacedName) []collector.Resource {
+	group := telemetryv1alpha1.SchemeGroupVersion.Group
+	resources := []collector.Resource{
+		{Group: group, Resource: telemetryv1alpha1.ResourcePluralClusterInfo, Verbs: []string{collector.VerbGet, collector.VerbList, collector.VerbCreate, collector.VerbDelete}},
+		{Group: group, Resource: telemetryv1alpha1.ResourcePluralClusterInfo + "/status", Verbs: []string{collector.VerbPatch}},
+	}
+	if credentialsSecret != nil {
+		resources = append(resources, collector.Resource{
+			Resource:      "secrets",
+			Verbs:         []string{collector.VerbGet},
+			Namespace:     credentialsSecret.Namespace,
+			ResourceNames: []string{credentialsSecret.Name},
+		})
+	}
+	return resources
 }
 
 type cloudReport struct {
-	cloudURL         string
-	cloudID          string
-	historyRetention time.Duration
-	client           runtimeclient.Client
-	discoveryClient  discovery.DiscoveryInterface
+	cloudURL  string
+	cloudID   string
+	retention RetentionPolicy
+	// compaction is disabled when it's nil.
+	compaction      *CompactionPolicy
+	client          runtimeclient.Client
+	discoveryClient discovery.DiscoveryInterface
+	// credentialsSecret the Secret which stores cloudId, token and client certificates.
+	credentialsSecret *types.NamespacedName
+	recorder          record.EventRecorder
+	// object the events which are not related to a ClusterInfo are recorded on.
+	object runtime.Object
+}
+
+func (k *cloudReport) eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...any) {
+	if k.recorder == nil || object == nil {
+		return
+	}
+	k.recorder.Eventf(object, eventtype, reason, messageFmt, args...)
 }
 
 // Save implements Report. save to crd(ClusterInfo). and report history crd to cloud.
-func (k cloudReport) Save(ctx context.Context, data map[string]any) error {
+func (k *cloudReport) Save(ctx context.Context, data map[string]any) error {
 	// check env
 	apiresources, err := k.discoveryClient.ServerPreferredResources()
 	if err != nil {
 		return err
 	}
 	for _, apiresource := range apiresources {
-		if apiresource.GroupVersion == CRDGroupVersionKind.GroupVersion().String() {
+		if apiresource.GroupVersion == telemetryv1alpha1.SchemeGroupVersion.String() {
 			return k.saveWithCRD(ctx, data)
 		}
 	}
 
 	data["product"] = ProductKS
-	return k.syncToCloud(ctx, data)
+	creds, err := k.credentials(ctx)
+	if err == nil {
+		err = k.syncToCloud(ctx, creds, data)
+	}
+	switch {
+	case errors.Is(err, errEmptyClusterID):
+		k.eventf(k.object, corev1.EventTypeWarning, reasonSyncSkipped, "skip to sync: %v", err)
+		return nil
+	case err != nil:
+		k.eventf(k.object, corev1.EventTypeWarning, eventReasonSyncFailed, "failed to sync to ksCloud: %v", err)
+		return err
+	}
+	k.eventf(k.object, corev1.EventTypeNormal, eventReasonSynced, "synced to ksCloud")
+	return nil
 }
 
-func (k cloudReport) saveWithCRD(ctx context.Context, data map[string]any) error {
+func (k *cloudReport) saveWithCRD(ctx context.Context, data map[string]any) error {
+	// compare current data with history crd
+	if err := k.setChanges(ctx, data); err != nil {
+		klog.Errorf("failed to compute changes from history. error is %v", err)
+	}
 	// save current data to a new crd
 	if err := k.saveCRD(ctx, data); err != nil {
 		return err
 	}
+	// down-sample history crd. it's optional, so the failure doesn't block the retention and sync.
+	var errs error
+	if err := k.compactCRD(ctx); err != nil {
+		klog.Errorf("failed to compact history. error is %v", err)
+		k.eventf(k.object, corev1.EventTypeWarning, eventReasonCompactFailed, "failed to compact history: %v", err)
+		errs = errors.Join(errs, err)
+	}
 	// delete expired crd
 	if err := k.expiredCRD(ctx); err != nil {
-		return err
+		return errors.Join(errs, err)
 	}
 	// sync crd to cloud
-	return k.syncCRD(ctx)
+	return errors.Join(errs, k.syncCRD(ctx))
 }
 
 func (k *cloudReport) saveCRD(ctx context.Context, data map[string]any) error {
-	clusterInfo := &unstructured.Unstructured{}
-	clusterInfo.SetGroupVersionKind(CRDGroupVersionKind)
-	ts, err := time.Parse(time.RFC3339, data["ts"].(string))
+	status := telemetryv1alpha1.ClusterInfoStatus{}
+	if err := fromMap(data, &status); err != nil {
+		return fmt.Errorf("failed to convert data to the status of clusterinfo: %w", err)
+	}
+	if status.TS == nil {
+		return fmt.Errorf("ts is not found in data")
+	}
+	status.ClusterCount = len(status.Clusters)
+	for _, cluster := range status.Clusters {
+		status.NodeCount += len(cluster.Nodes)
+	}
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:    telemetryv1alpha1.ConditionTypeCollected,
+		Status:  metav1.ConditionTrue,
+		Reason:  reasonDataCollected,
+		Message: fmt.Sprintf("collected data of %d clusters", status.ClusterCount),
+	})
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:    telemetryv1alpha1.ConditionTypeSynced,
+		Status:  metav1.ConditionFalse,
+		Reason:  reasonWaitingForSync,
+		Message: "waiting to sync to ksCloud",
+	})
+	run, _ := RunFrom(ctx)
+	labels, annotations := runMetadata(run)
+	base := status.TS.UTC().Format("20060102150405")
+	for i := 0; i <= maxNameSuffix; i++ {
+		name := base
+		if i > 0 {
+			name = fmt.Sprintf("%s-%d", base, i)
+		}
+		clusterInfo := &telemetryv1alpha1.ClusterInfo{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels, Annotations: annotations},
+		}
+		// create crd
+		err := k.client.Create(ctx, clusterInfo)
+		if err == nil {
+			return k.saveStatus(ctx, clusterInfo, status)
+		}
+		if !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+		// another run has created the crd in the same second
+		existing := &telemetryv1alpha1.ClusterInfo{}
+		if err := k.client.Get(ctx, types.NamespacedName{Name: name}, existing); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if !isDuplicate(existing, run.ID, status) {
+			continue
+		}
+		if meta.IsStatusConditionTrue(existing.Status.Conditions, telemetryv1alpha1.ConditionTypeCollected) {
+			klog.Infof("the data has been saved in %s. skip", name)
+			return nil
+		}
+		// the status is not saved by the previous attempt of the run
+		return k.saveStatus(ctx, existing, status)
+	}
+	return fmt.Errorf("clusterinfo %s to %s-%d already exist with different data. the data is not saved", base, base, maxNameSuffix)
+}
+
+// saveStatus saves the collected data to the status of clusterInfo.
+func (k *cloudReport) saveStatus(ctx context.Context, clusterInfo *telemetryv1alpha1.ClusterInfo, status telemetryv1alpha1.ClusterInfoStatus) error {
+	newClusterInfo := clusterInfo.DeepCopy()
+	newClusterInfo.Status = status
+	if err := k.client.Status().Patch(ctx, newClusterInfo, runtimeclient.MergeFrom(clusterInfo)); err != nil {
+		return err
+	}
+	k.eventf(newClusterInfo, corev1.EventTypeNormal, eventReasonCollected, "collected data of %d clusters and %d nodes", status.ClusterCount, status.NodeCount)
+	return nil
+}
+
+// runMetadata returns the labels and annotations of ClusterInfo which identify the run.
+func runMetadata(run Run) (map[string]string, map[string]string) {
+	labels, annotations := make(map[string]string), make(map[string]string)
+	if run.ID != "" {
+		labels[telemetryv1alpha1.LabelRunID] = run.ID
+	}
+	if len(run.Collectors) != 0 {
+		collectors := append([]string(nil), run.Collectors...)
+		sort.Strings(collectors)
+		// label value is limited to 63 characters. the names are stored in annotation.
+		sum := sha256.Sum256([]byte(strings.Join(collectors, ",")))
+		labels[telemetryv1alpha1.LabelCollectorSet] = hex.EncodeToString(sum[:])[:16]
+		annotations[telemetryv1alpha1.AnnotationCollectors] = strings.Join(collectors, ",")
+	}
+	return labels, annotations
+}
+
+// isDuplicate reports whether existing stores the same data as status, which is created by the same run
+// or by another run which collects at the same time.
+func isDuplicate(existing *telemetryv1alpha1.ClusterInfo, runID string, status telemetryv1alpha1.ClusterInfoStatus) bool {
+	if runID != "" && existing.Labels[telemetryv1alpha1.LabelRunID] == runID {
+		return true
+	}
+	if existing.Status.TS == nil {
+		return false
+	}
+	existingData, err := telemetryData(existing.Status)
+	if err != nil {
+		return false
+	}
+	data, err := telemetryData(status)
+	if err != nil {
+		return false
+	}
+	// changes is computed from the history which differs between runs.
+	delete(existingData, "changes")
+	delete(data, "changes")
+	return reflect.DeepEqual(existingData, data)
+}
+
+// GetClusterInfoData returns the telemetry data stored in the status of ClusterInfo.
+func GetClusterInfoData(ctx context.Context, client runtimeclient.Client, name string) (map[string]any, error) {
+	clusterInfo := &telemetryv1alpha1.ClusterInfo{}
+	if err := client.Get(ctx, types.NamespacedName{Name: name}, clusterInfo); err != nil {
+		return nil, err
+	}
+	return toMap(clusterInfo.Status)
+}
+
+// ListClusterInfos returns all ClusterInfo sorted by the time of collection.
+func ListClusterInfos(ctx context.Context, client runtimeclient.Client) ([]telemetryv1alpha1.ClusterInfo, error) {
+	clusterInfoList := &telemetryv1alpha1.ClusterInfoList{}
+	if err := client.List(ctx, clusterInfoList); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(clusterInfoList.Items, func(i, j int) bool {
+		return ClusterInfoTime(&clusterInfoList.Items[i]).Before(ClusterInfoTime(&clusterInfoList.Items[j]))
+	})
+	return clusterInfoList.Items, nil
+}
+
+// ClusterInfoTime returns the time when the data of clusterInfo is collected.
+// the creationTimestamp is used when status.ts is missing.
+func ClusterInfoTime(clusterInfo *telemetryv1alpha1.ClusterInfo) time.Time {
+	if clusterInfo.Status.TS != nil {
+		return clusterInfo.Status.TS.Time
+	}
+	return clusterInfo.CreationTimestamp.Time
+}
+
+// setChanges sets the lifecycle of clusters and the changes relative to the previous crd to data.
+func (k *cloudReport) setChanges(ctx context.Context, data map[string]any) error {
+	clusterInfos, err := ListClusterInfos(ctx, k.client)
+	if err != nil {
+		return err
+	}
+	history := make([]*snapshot.Snapshot, 0, len(clusterInfos))
+	for _, clusterInfo := range clusterInfos {
+		s, err := snapshot.Decode(clusterInfo.Status)
+		if err != nil {
+			klog.Errorf("failed to decode status of %s. error is %v", clusterInfo.Name, err)
+			continue
+		}
+		history = append(history, s)
+	}
+	current, err := snapshot.Decode(data)
 	if err != nil {
 		return err
 	}
-	clusterInfo.SetName(ts.UTC().Format("20060102150405"))
-	// create crd
-	if err := k.client.Create(ctx, clusterInfo); err != nil {
+	changes, err := toMap(snapshot.ComputeChanges(history, current))
+	if err != nil {
 		return err
 	}
-	// set status to crd
-	// update clusterInfo status
-	newClusterInfo := clusterInfo.DeepCopy()
-	if err := unstructured.SetNestedMap(newClusterInfo.Object, data, "status"); err != nil {
+	data["changes"] = changes
+	return nil
+}
+
+func (k *cloudReport) compactCRD(ctx context.Context) error {
+	if k.compaction == nil {
+		return nil
+	}
+	clusterInfos, err := ListClusterInfos(ctx, k.client)
+	if err != nil {
 		return err
 	}
-	return k.client.Status().Patch(ctx, newClusterInfo, runtimeclient.MergeFrom(clusterInfo.DeepCopy()))
+	compacted := k.compaction.Compact(clusterInfos, time.Now())
+	for _, clusterInfo := range compacted {
+		if derr := k.client.Delete(ctx, &clusterInfo); derr != nil {
+			k.eventf(&clusterInfo, corev1.EventTypeWarning, eventReasonDeleteFailed, "failed to delete compacted clusterInfo: %v", derr)
+			err = errors.Join(err, derr)
+			continue
+		}
+		k.eventf(&clusterInfo, corev1.EventTypeNormal, eventReasonCompacted, "deleted by history compaction")
+	}
+	klog.Infof("history compaction: compacted %d of %d clusterInfo", len(compacted), len(clusterInfos))
+	return err
 }
 
 func (k *cloudReport) expiredCRD(ctx context.Context) error {
-	clusterInfoList := &unstructured.UnstructuredList{}
-	clusterInfoList.SetGroupVersionKind(CRDListGroupVersionKind)
-	if err := k.client.List(ctx, clusterInfoList); err != nil {
+	clusterInfos, err := ListClusterInfos(ctx, k.client)
+	if err != nil {
 		return err
 	}
-	var err error
-	for _, clusterInfo := range clusterInfoList.Items {
-		if clusterInfo.GetCreationTimestamp().Add(k.historyRetention).Before(time.Now()) {
-			err = errors.Join(err, k.client.Delete(ctx, &clusterInfo))
+	summary := k.retention.Prune(clusterInfos, time.Now())
+	for _, pruned := range summary.Pruned {
+		clusterInfo := pruned.ClusterInfo
+		if derr := k.client.Delete(ctx, &clusterInfo); derr != nil {
+			k.eventf(&clusterInfo, corev1.EventTypeWarning, eventReasonDeleteFailed, "failed to delete expired clusterInfo: %v", derr)
+			err = errors.Join(err, derr)
+			continue
 		}
+		k.eventf(&clusterInfo, corev1.EventTypeNormal, eventReasonExpired, "deleted by history retention. reason: %s", pruned.Reason)
+	}
+	klog.Infof("history retention: %s", summary)
+	if summary.KeptUnsynced > 0 {
+		k.eventf(k.object, corev1.EventTypeWarning, eventReasonUnsyncedKept,
+			"kept %d unsynced clusterInfo exceeding the history limits. set historyPruneUnsynced to prune them", summary.KeptUnsynced)
 	}
 	return err
 }
 
 func (k *cloudReport) syncCRD(ctx context.Context) error {
-	clusterInfoList := &unstructured.UnstructuredList{}
-	clusterInfoList.SetGroupVersionKind(CRDListGroupVersionKind)
-	if err := k.client.List(ctx, clusterInfoList); err != nil {
+	clusterInfos, err := ListClusterInfos(ctx, k.client)
+	if err != nil {
 		return err
 	}
+	// credentials are read once when there is a crd to sync. the failure is recorded on each crd as a sync failure.
+	var (
+		creds    *credentials
+		credsErr error
+		credsGot bool
+	)
 	var errs error
-	for _, clusterInfo := range clusterInfoList.Items {
-		if clusterInfo.GetDeletionTimestamp() != nil { // ctd is deleted
+	now := time.Now()
+	for _, clusterInfo := range clusterInfos {
+		if clusterInfo.DeletionTimestamp != nil { // ctd is deleted
 			continue
 		}
-		if _, found, err := unstructured.NestedFieldCopy(clusterInfo.Object, "status", "syncTime"); err == nil && found { // crd is synced
+		if clusterInfo.Status.SyncTime != nil { // crd is synced
+			continue
+		}
+		if IsSyncPermanentlyFailed(&clusterInfo) {
+			continue
+		}
+		if next := clusterInfo.Status.NextSyncTime; next != nil && now.Before(next.Time) { // wait for backoff
+			klog.Infof("skip to sync %s before %s", clusterInfo.Name, next.UTC().Format(time.RFC3339))
 			continue
 		}
 
-		data, found, err := unstructured.NestedMap(clusterInfo.Object, "status")
-		if err != nil || !found {
-			errs = errors.Join(errs, fmt.Errorf("failed to get status from %s. error is %v or not found", clusterInfo.GetName(), err))
+		data, err := telemetryData(clusterInfo.Status)
+		if err != nil {
+			errs = errors.Join(errs, fmt.Errorf("failed to get status from %s. error is %v", clusterInfo.Name, err))
+			continue
 		}
 		data["product"] = ProductKSE
-		err = k.syncToCloud(ctx, data)
-		if err != nil { // sync failed
-			errs = errors.Join(errs, fmt.Errorf("failed to sync %s to cloud. error is %v", clusterInfo.GetName(), err))
-		} else { // sync success. add syncTime to clusterInfo
-			newClusterInfo := clusterInfo.DeepCopy()
-			if err := unstructured.SetNestedField(newClusterInfo.Object, metav1.Now().UTC().Format(time.RFC3339), "status", "syncTime"); err != nil {
-				errs = errors.Join(errs, fmt.Errorf("failed to set syncTime filed in %s. error is %v", clusterInfo.GetName(), err))
-			}
-			if err := k.client.Status().Patch(ctx, newClusterInfo, runtimeclient.MergeFrom(clusterInfo.DeepCopy())); err != nil {
-				errs = errors.Join(errs, fmt.Errorf("failed to patch syncTime filed in %s. error is %v", clusterInfo.GetName(), err))
-			}
+		newClusterInfo := clusterInfo.DeepCopy()
+		newClusterInfo.Status.SyncAttempts++
+		if !credsGot {
+			creds, credsErr = k.credentials(ctx)
+			credsGot = true
+		}
+		err = credsErr
+		if err == nil {
+			err = k.syncToCloud(ctx, creds, data)
+		}
+		switch {
+		case errors.Is(err, errEmptyClusterID): // nothing to sync. don't retry it
+			setSynced(&newClusterInfo.Status, reasonSyncSkipped, err.Error())
+			k.eventf(&clusterInfo, corev1.EventTypeWarning, reasonSyncSkipped, "skip to sync: %v", err)
+		case err != nil: // sync failed
+			errs = errors.Join(errs, fmt.Errorf("failed to sync %s to cloud. error is %v", clusterInfo.Name, err))
+			setSyncFailed(&newClusterInfo.Status, err, now)
+			k.eventf(&clusterInfo, corev1.EventTypeWarning, eventReasonSyncFailed, "failed to sync to ksCloud: %v", err)
+		default: // sync success. add syncTime to clusterInfo
+			setSynced(&newClusterInfo.Status, reasonSyncSucceeded, "synced to ksCloud")
+			k.eventf(&clusterInfo, corev1.EventTypeNormal, eventReasonSynced, "synced to ksCloud")
+		}
+		if err := k.client.Status().Patch(ctx, newClusterInfo, runtimeclient.MergeFrom(&clusterInfo)); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("failed to patch sync status in %s. error is %v", clusterInfo.Name, err))
 		}
 	}
 	return errs
 }
 
-func (k *cloudReport) syncToCloud(ctx context.Context, data map[string]any) error {
+// telemetryData returns the telemetry data in status without the fields which track the sync.
+func telemetryData(status telemetryv1alpha1.ClusterInfoStatus) (map[string]any, error) {
+	status.SyncTime = nil
+	status.Conditions = nil
+	status.SyncAttempts = 0
+	status.LastSyncError = ""
+	status.SyncFailures = 0
+	status.SyncClientErrors = 0
+	status.NextSyncTime = nil
+	status.ClusterCount = 0
+	status.NodeCount = 0
+	return toMap(status)
+}
+
+// IsSyncPermanentlyFailed reports whether clusterInfo is rejected by ksCloud too many times and won't be synced again.
+func IsSyncPermanentlyFailed(clusterInfo *telemetryv1alpha1.ClusterInfo) bool {
+	c := meta.FindStatusCondition(clusterInfo.Status.Conditions, telemetryv1alpha1.ConditionTypeSyncFailed)
+	return c != nil && c.Status == metav1.ConditionTrue && c.Reason == reasonPermanentFailure
+}
+
+func setSynced(status *telemetryv1alpha1.ClusterInfoStatus, reason, message string) {
+	status.SyncTime = ptr.To(metav1.Now())
+	status.LastSyncError = ""
+	status.SyncFailures = 0
+	status.NextSyncTime = nil
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:    telemetryv1alpha1.ConditionTypeSynced,
+		Status:  metav1.ConditionTrue,
+		Reason:  reason,
+		Message: message,
+	})
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:    telemetryv1alpha1.ConditionTypeSyncFailed,
+		Status:  metav1.ConditionFalse,
+		Reason:  reason,
+		Message: "",
+	})
+}
+
+// setSyncFailed records the failure and sets when to retry with exponential backoff.
+func setSyncFailed(status *telemetryv1alpha1.ClusterInfoStatus, err error, now time.Time) {
+	status.LastSyncError = err.Error()
+	status.SyncFailures++
+	if isClientError(err) {
+		status.SyncClientErrors++
+	}
+	if status.SyncClientErrors >= maxSyncClientErrors {
+		status.NextSyncTime = nil
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:    telemetryv1alpha1.ConditionTypeSyncFailed,
+			Status:  metav1.ConditionTrue,
+			Reason:  reasonPermanentFailure,
+			Message: fmt.Sprintf("rejected by ksCloud %d times and won't be synced again: %v", status.SyncClientErrors, err),
+		})
+		return
+	}
+	backoff := syncBackoff
+	for i := 1; i < status.SyncFailures && backoff < maxSyncBackoff; i++ {
+		backoff *= 2
+	}
+	status.NextSyncTime = ptr.To(metav1.NewTime(now.Add(min(backoff, maxSyncBackoff))))
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:    telemetryv1alpha1.ConditionTypeSyncFailed,
+		Status:  metav1.ConditionTrue,
+		Reason:  reasonRequestFailed,
+		Message: err.Error(),
+	})
+}
+
+// responseError the response of ksCloud is not ok.
+type responseError struct {
+	statusCode int
+	body       string
+}
+
+func (e *responseError) Error() string {
+	return fmt.Sprintf("resp code expect %v, but get code %v %s", http.StatusOK, e.statusCode, e.body)
+}
+
+// isClientError reports whether ksCloud rejects the data with a 4xx response.
+// the responses caused by credentials or rate limit are excluded, which are not related to the data.
+func isClientError(err error) bool {
+	var respErr *responseError
+	if !errors.As(err, &respErr) {
+		return false
+	}
+	switch respErr.statusCode {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return false
+	}
+	return respErr.statusCode >= 400 && respErr.statusCode < 500
+}
+
+func (k *cloudReport) syncToCloud(ctx context.Context, creds *credentials, data map[string]any) error {
 	// get clusterId from data
 	clusterId := ""
-	for _, cluster := range data["clusters"].([]any) {
+	clusters, _ := data["clusters"].([]any)
+	for _, cluster := range clusters {
 		if cluster.(map[string]any)["role"] == "host" {
 			clusterId = cluster.(map[string]any)["nid"].(string)
 		}
 	}
 	if clusterId == "" { // When the data has not been collected yet
 		klog.Infof("clusterId is empty. skip sync")
-		return nil
+		return errEmptyClusterID
 	}
-	data["cloudId"] = k.cloudID
+	data["cloudId"] = creds.cloudID
 
 	// convert req data
-	reqData, err := json.Marshal(data)
+	telemetryReq, err := json.Marshal(struct {
+		UserID string         `json:"user_id"`
+		Data   map[string]any `json:"data"`
+	}{UserID: creds.cloudID, Data: data})
 	if err != nil {
 		klog.Errorf("convert clusterInfo data status to json error %v", err)
 		return err
 	}
 
-	telemetryReq := fmt.Sprintf(`{ "user_id": "%s","data": %s }`, k.cloudID, string(reqData))
-	request, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s%s", k.cloudURL, strings.ReplaceAll(defaultTelemetryEndpoint, "${cluster_id}", clusterId)), bytes.NewBufferString(telemetryReq))
+	request, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s%s", k.cloudURL, strings.ReplaceAll(defaultTelemetryEndpoint, "${cluster_id}", clusterId)), bytes.NewReader(telemetryReq))
 	if err != nil {
 		klog.Errorf("new request for cloud error %v", err)
 		return err
 	}
 	request.Header.Set("Content-Type", "application/json")
-	resp, err := KSCloudClient.Do(request)
+	if creds.token != "" {
+		request.Header.Set("Authorization", "Bearer "+creds.token)
+	}
+	resp, err := creds.httpClient.Do(request)
 	if err != nil {
 		klog.Errorf("do request for cloud error %v", err)
 		return err
@@ -198,7 +641,8 @@ func (k *cloudReport) syncToCloud(ctx context.Context, data map[string]any) erro
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("resp code expect %v, but get code %v ", http.StatusOK, resp.StatusCode)
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &responseError{statusCode: resp.StatusCode, body: strings.TrimSpace(string(body))}
 	}
 	klog.Infof("Send data to kubesphere cloud success")
 	return nil
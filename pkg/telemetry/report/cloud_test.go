@@ -0,0 +1,326 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	telemetryv1alpha1 "kubesphere.io/telemetry/pkg/apis/telemetry/v1alpha1"
+	"kubesphere.io/telemetry/pkg/telemetry/collector"
+)
+
+func TestIsClientError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "not response error", err: errors.New("connection refused"), want: false},
+		{name: "bad request", err: &responseError{statusCode: http.StatusBadRequest}, want: true},
+		{name: "not found", err: &responseError{statusCode: http.StatusNotFound}, want: true},
+		{name: "entity too large", err: &responseError{statusCode: http.StatusRequestEntityTooLarge}, want: true},
+		{name: "unprocessable entity", err: &responseError{statusCode: http.StatusUnprocessableEntity}, want: true},
+		{name: "wrapped", err: fmt.Errorf("sync: %w", &responseError{statusCode: http.StatusBadRequest}), want: true},
+		{name: "unauthorized", err: &responseError{statusCode: http.StatusUnauthorized}, want: false},
+		{name: "forbidden", err: &responseError{statusCode: http.StatusForbidden}, want: false},
+		{name: "request timeout", err: &responseError{statusCode: http.StatusRequestTimeout}, want: false},
+		{name: "too many requests", err: &responseError{statusCode: http.StatusTooManyRequests}, want: false},
+		{name: "server error", err: &responseError{statusCode: http.StatusInternalServerError}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isClientError(tt.err); got != tt.want {
+				t.Errorf("isClientError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetSyncFailedBackoff(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 1, want: 5 * time.Minute},
+		{failures: 2, want: 10 * time.Minute},
+		{failures: 3, want: 20 * time.Minute},
+		{failures: 9, want: 1280 * time.Minute},
+		{failures: 10, want: maxSyncBackoff},
+		{failures: 30, want: maxSyncBackoff},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d failures", tt.failures), func(t *testing.T) {
+			status := telemetryv1alpha1.ClusterInfoStatus{}
+			for i := 0; i < tt.failures; i++ {
+				setSyncFailed(&status, &responseError{statusCode: http.StatusServiceUnavailable}, testNow)
+			}
+			if status.SyncFailures != tt.failures {
+				t.Errorf("syncFailures = %d, want %d", status.SyncFailures, tt.failures)
+			}
+			if status.NextSyncTime == nil {
+				t.Fatal("nextSyncTime is nil")
+			}
+			if got := status.NextSyncTime.Sub(testNow); got != tt.want {
+				t.Errorf("backoff = %v, want %v", got, tt.want)
+			}
+			if IsSyncPermanentlyFailed(&telemetryv1alpha1.ClusterInfo{Status: status}) {
+				t.Error("server errors are permanently failed")
+			}
+		})
+	}
+}
+
+func TestSetSyncFailedPermanently(t *testing.T) {
+	tests := []struct {
+		name  string
+		codes []int
+		// want whether the ClusterInfo is permanently failed after each error
+		want []bool
+	}{
+		{
+			name:  "client errors",
+			codes: []int{http.StatusBadRequest, http.StatusBadRequest, http.StatusBadRequest},
+			want:  []bool{false, false, true},
+		},
+		{
+			name:  "client errors not consecutive",
+			codes: []int{http.StatusBadRequest, http.StatusBadGateway, http.StatusUnprocessableEntity, http.StatusBadGateway, http.StatusNotFound},
+			want:  []bool{false, false, false, false, true},
+		},
+		{
+			name:  "credentials",
+			codes: []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusUnauthorized, http.StatusForbidden},
+			want:  []bool{false, false, false, false},
+		},
+		{
+			name:  "rate limit and timeout",
+			codes: []int{http.StatusTooManyRequests, http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusRequestTimeout},
+			want:  []bool{false, false, false, false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusterInfo := &telemetryv1alpha1.ClusterInfo{}
+			for i, code := range tt.codes {
+				err := &responseError{statusCode: code}
+				setSyncFailed(&clusterInfo.Status, err, testNow)
+				if clusterInfo.Status.LastSyncError != err.Error() {
+					t.Errorf("lastSyncError = %q, want %q", clusterInfo.Status.LastSyncError, err.Error())
+				}
+				got := IsSyncPermanentlyFailed(clusterInfo)
+				if got != tt.want[i] {
+					t.Errorf("permanently failed after %d errors = %v, want %v", i+1, got, tt.want[i])
+				}
+				if got != (clusterInfo.Status.NextSyncTime == nil) {
+					t.Errorf("nextSyncTime = %v, permanently failed %v", clusterInfo.Status.NextSyncTime, got)
+				}
+			}
+		})
+	}
+}
+
+func TestSaveCRD(t *testing.T) {
+	ts := metav1.NewTime(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))
+	const base = "20240501000000"
+	data := func(cloudID string) telemetryv1alpha1.ClusterInfoStatus {
+		return telemetryv1alpha1.ClusterInfoStatus{TS: &ts, CloudID: cloudID}
+	}
+	// existing returns a ClusterInfo which is saved by the run with data. the status is not saved when data is nil.
+	existing := func(name, runID string, data *telemetryv1alpha1.ClusterInfoStatus) *telemetryv1alpha1.ClusterInfo {
+		clusterInfo := &telemetryv1alpha1.ClusterInfo{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{telemetryv1alpha1.LabelRunID: runID}},
+		}
+		if data != nil {
+			clusterInfo.Status = *data
+			meta.SetStatusCondition(&clusterInfo.Status.Conditions, metav1.Condition{
+				Type:   telemetryv1alpha1.ConditionTypeCollected,
+				Status: metav1.ConditionTrue,
+				Reason: reasonDataCollected,
+			})
+		}
+		return clusterInfo
+	}
+	// taken returns the ClusterInfo with the names from base to the suffix n which store different data.
+	taken := func(n int) []runtimeclient.Object {
+		var objects []runtimeclient.Object
+		for i := 0; i <= n; i++ {
+			name := base
+			if i > 0 {
+				name = fmt.Sprintf("%s-%d", base, i)
+			}
+			objects = append(objects, existing(name, "other", ptr.To(data(fmt.Sprintf("other-%d", i)))))
+		}
+		return objects
+	}
+
+	tests := []struct {
+		name     string
+		existing []runtimeclient.Object
+		// wantSaved the name of ClusterInfo which stores the data. empty when the data is skipped.
+		wantSaved string
+		wantCount int
+		wantErr   bool
+	}{
+		{
+			name:      "new",
+			wantSaved: base,
+			wantCount: 1,
+		},
+		{
+			name:      "saved by the same run",
+			existing:  []runtimeclient.Object{existing(base, "run", ptr.To(data("other")))},
+			wantCount: 1,
+		},
+		{
+			name:      "status not saved by the same run",
+			existing:  []runtimeclient.Object{existing(base, "run", nil)},
+			wantSaved: base,
+			wantCount: 1,
+		},
+		{
+			name:      "same content by another run",
+			existing:  []runtimeclient.Object{existing(base, "other", ptr.To(data("cloud")))},
+			wantCount: 1,
+		},
+		{
+			name:      "different content",
+			existing:  taken(0),
+			wantSaved: base + "-1",
+			wantCount: 2,
+		},
+		{
+			name:      "next suffix",
+			existing:  taken(1),
+			wantSaved: base + "-2",
+			wantCount: 3,
+		},
+		{
+			name:      "max suffix",
+			existing:  taken(8),
+			wantSaved: base + "-9",
+			wantCount: 10,
+		},
+		{
+			name:      "duplicate with max suffix",
+			existing:  append(taken(8), existing(base+"-9", "other", ptr.To(data("cloud")))),
+			wantCount: 10,
+		},
+		{
+			name:      "all names taken",
+			existing:  taken(maxNameSuffix),
+			wantCount: maxNameSuffix + 1,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewClientBuilder().WithScheme(collector.Schema).
+				WithStatusSubresource(&telemetryv1alpha1.ClusterInfo{}).
+				WithObjects(tt.existing...).Build()
+			k := &cloudReport{client: client}
+			m, err := toMap(data("cloud"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := WithRun(context.Background(), Run{ID: "run"})
+			if err := k.saveCRD(ctx, m); (err != nil) != tt.wantErr {
+				t.Fatalf("saveCRD() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			clusterInfos, err := ListClusterInfos(ctx, client)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(clusterInfos) != tt.wantCount {
+				t.Errorf("count = %d, want %d", len(clusterInfos), tt.wantCount)
+			}
+			for _, clusterInfo := range clusterInfos {
+				saved := clusterInfo.Labels[telemetryv1alpha1.LabelRunID] == "run" && clusterInfo.Status.CloudID == "cloud" &&
+					meta.IsStatusConditionTrue(clusterInfo.Status.Conditions, telemetryv1alpha1.ConditionTypeCollected)
+				if saved != (clusterInfo.Name == tt.wantSaved) {
+					t.Errorf("data saved in %s = %v, want saved in %q", clusterInfo.Name, saved, tt.wantSaved)
+				}
+			}
+		})
+	}
+}
+
+func TestIsDuplicate(t *testing.T) {
+	ts := metav1.NewTime(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))
+	status := telemetryv1alpha1.ClusterInfoStatus{TS: &ts, CloudID: "cloud"}
+	tests := []struct {
+		name     string
+		existing telemetryv1alpha1.ClusterInfo
+		runID    string
+		want     bool
+	}{
+		{
+			name:     "same run",
+			existing: telemetryv1alpha1.ClusterInfo{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{telemetryv1alpha1.LabelRunID: "run"}}},
+			runID:    "run",
+			want:     true,
+		},
+		{
+			name:     "no run id",
+			existing: telemetryv1alpha1.ClusterInfo{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{telemetryv1alpha1.LabelRunID: ""}}},
+			want:     false,
+		},
+		{
+			name:     "same content",
+			existing: telemetryv1alpha1.ClusterInfo{Status: telemetryv1alpha1.ClusterInfoStatus{TS: &ts, CloudID: "cloud"}},
+			runID:    "run",
+			want:     true,
+		},
+		{
+			name: "sync status and changes ignored",
+			existing: telemetryv1alpha1.ClusterInfo{Status: telemetryv1alpha1.ClusterInfoStatus{
+				TS: &ts, CloudID: "cloud", SyncTime: &ts, SyncAttempts: 2, ClusterCount: 1, Changes: &telemetryv1alpha1.Changes{},
+			}},
+			runID: "run",
+			want:  true,
+		},
+		{
+			name:     "different content",
+			existing: telemetryv1alpha1.ClusterInfo{Status: telemetryv1alpha1.ClusterInfoStatus{TS: &ts, CloudID: "other"}},
+			runID:    "run",
+			want:     false,
+		},
+		{
+			name:     "status not saved by another run",
+			existing: telemetryv1alpha1.ClusterInfo{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{telemetryv1alpha1.LabelRunID: "other"}}},
+			runID:    "run",
+			want:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isDuplicate(&tt.existing, tt.runID, status); got != tt.want {
+				t.Errorf("isDuplicate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
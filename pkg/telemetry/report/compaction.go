@@ -0,0 +1,82 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"fmt"
+	"time"
+
+	telemetryv1alpha1 "kubesphere.io/telemetry/pkg/apis/telemetry/v1alpha1"
+)
+
+// CompactionPolicy down-samples the old ClusterInfo. every ClusterInfo collected in KeepAll is kept.
+// the older ones are grouped into buckets of one day until Daily, one week until Weekly and one month after,
+// and only the first and the last ClusterInfo of each bucket are kept.
+// the ClusterInfo which is not synced to ksCloud is always kept.
+type CompactionPolicy struct {
+	KeepAll time.Duration
+	Daily   time.Duration
+	Weekly  time.Duration
+}
+
+// Compact returns the ClusterInfo to delete. clusterInfos are sorted by the time of collection, see ListClusterInfos.
+func (p CompactionPolicy) Compact(clusterInfos []telemetryv1alpha1.ClusterInfo, now time.Time) []telemetryv1alpha1.ClusterInfo {
+	// index of the clusterInfos in each bucket by order
+	buckets := make(map[string][]int)
+	var keys []string
+	for i := range clusterInfos {
+		key := p.bucket(ClusterInfoTime(&clusterInfos[i]), now)
+		if key == "" {
+			continue
+		}
+		if _, ok := buckets[key]; !ok {
+			keys = append(keys, key)
+		}
+		buckets[key] = append(buckets[key], i)
+	}
+	var compacted []telemetryv1alpha1.ClusterInfo
+	for _, key := range keys {
+		bucket := buckets[key]
+		if len(bucket) <= 2 {
+			continue
+		}
+		// keep the first and the last
+		for _, i := range bucket[1 : len(bucket)-1] {
+			if isUnsynced(&clusterInfos[i]) {
+				continue
+			}
+			compacted = append(compacted, clusterInfos[i])
+		}
+	}
+	return compacted
+}
+
+// bucket returns the bucket of the ClusterInfo collected at ts. it's empty when the ClusterInfo should be kept.
+func (p CompactionPolicy) bucket(ts, now time.Time) string {
+	age := now.Sub(ts)
+	ts = ts.UTC()
+	switch {
+	case age < p.KeepAll:
+		return ""
+	case age < p.Daily:
+		return "day/" + ts.Format(time.DateOnly)
+	case age < p.Weekly:
+		year, week := ts.ISOWeek()
+		return fmt.Sprintf("week/%d-%02d", year, week)
+	}
+	return "month/" + ts.Format("2006-01")
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	telemetryv1alpha1 "kubesphere.io/telemetry/pkg/apis/telemetry/v1alpha1"
+)
+
+// collectedAt returns a ClusterInfo collected at ts in state.
+func collectedAt(name, ts, state string) telemetryv1alpha1.ClusterInfo {
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		panic(err)
+	}
+	return newClusterInfo(name, testNow.Sub(t), state)
+}
+
+func TestCompactionPolicyCompact(t *testing.T) {
+	// testNow is 2024-05-15T12:00:00Z, Wednesday of ISO week 20.
+	policy := CompactionPolicy{KeepAll: 2 * 24 * time.Hour, Daily: 10 * 24 * time.Hour, Weekly: 60 * 24 * time.Hour}
+	tests := []struct {
+		name         string
+		clusterInfos []telemetryv1alpha1.ClusterInfo
+		want         []string
+	}{
+		{
+			name: "keep all",
+			clusterInfos: []telemetryv1alpha1.ClusterInfo{
+				collectedAt("a", "2024-05-14T00:00:00Z", synced),
+				collectedAt("b", "2024-05-14T06:00:00Z", synced),
+				collectedAt("c", "2024-05-14T12:00:00Z", synced),
+			},
+		},
+		{
+			name: "first and last of a day",
+			clusterInfos: []telemetryv1alpha1.ClusterInfo{
+				collectedAt("a", "2024-05-10T00:00:00Z", synced),
+				collectedAt("b", "2024-05-10T06:00:00Z", synced),
+				collectedAt("c", "2024-05-10T12:00:00Z", synced),
+				collectedAt("d", "2024-05-10T23:59:59Z", synced),
+			},
+			want: []string{"b", "c"},
+		},
+		{
+			name: "day edge",
+			clusterInfos: []telemetryv1alpha1.ClusterInfo{
+				collectedAt("a", "2024-05-10T00:00:00Z", synced),
+				collectedAt("b", "2024-05-10T23:59:59Z", synced),
+				collectedAt("c", "2024-05-11T00:00:00Z", synced),
+				collectedAt("d", "2024-05-11T12:00:00Z", synced),
+			},
+		},
+		{
+			name: "days are in utc",
+			clusterInfos: []telemetryv1alpha1.ClusterInfo{
+				collectedAt("a", "2024-05-10T00:00:00Z", synced),
+				// 2024-05-10T23:00:00Z
+				collectedAt("b", "2024-05-11T07:00:00+08:00", synced),
+				collectedAt("c", "2024-05-10T23:30:00Z", synced),
+			},
+			want: []string{"b"},
+		},
+		{
+			name: "iso week edge",
+			clusterInfos: []telemetryv1alpha1.ClusterInfo{
+				// Monday and Sunday of week 16
+				collectedAt("a", "2024-04-15T00:00:00Z", synced),
+				collectedAt("b", "2024-04-17T00:00:00Z", synced),
+				collectedAt("c", "2024-04-21T23:59:59Z", synced),
+				// Monday of week 17
+				collectedAt("d", "2024-04-22T00:00:00Z", synced),
+				collectedAt("e", "2024-04-24T00:00:00Z", synced),
+			},
+			want: []string{"b"},
+		},
+		{
+			name: "month edge",
+			clusterInfos: []telemetryv1alpha1.ClusterInfo{
+				collectedAt("a", "2024-02-01T00:00:00Z", synced),
+				collectedAt("b", "2024-02-15T00:00:00Z", synced),
+				collectedAt("c", "2024-02-29T23:59:59Z", synced),
+				collectedAt("d", "2024-03-01T00:00:00Z", synced),
+				collectedAt("e", "2024-03-02T00:00:00Z", synced),
+			},
+			want: []string{"b"},
+		},
+		{
+			name: "bucket changes with age",
+			clusterInfos: []telemetryv1alpha1.ClusterInfo{
+				// Daily ends at 2024-05-05T12:00:00Z. a and b are in the bucket of week 18, c and d in the bucket of the day
+				collectedAt("a", "2024-05-05T00:00:00Z", synced),
+				collectedAt("b", "2024-05-05T11:00:00Z", synced),
+				collectedAt("c", "2024-05-05T13:00:00Z", synced),
+				collectedAt("d", "2024-05-05T23:00:00Z", synced),
+			},
+		},
+		{
+			name: "unsynced are never compacted",
+			clusterInfos: []telemetryv1alpha1.ClusterInfo{
+				collectedAt("a", "2024-05-10T00:00:00Z", synced),
+				collectedAt("b", "2024-05-10T06:00:00Z", unsynced),
+				collectedAt("c", "2024-05-10T12:00:00Z", failed),
+				collectedAt("d", "2024-05-10T18:00:00Z", synced),
+				collectedAt("e", "2024-05-10T23:00:00Z", synced),
+			},
+			want: []string{"c", "d"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, clusterInfo := range policy.Compact(tt.clusterInfos, testNow) {
+				got = append(got, clusterInfo.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Compact() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
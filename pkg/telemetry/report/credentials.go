@@ -0,0 +1,122 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// keys of the credentials Secret
+	SecretKeyCloudID = "cloudId"
+	SecretKeyToken   = "token"
+	SecretKeyCert    = corev1.TLSCertKey
+	SecretKeyKey     = corev1.TLSPrivateKeyKey
+	SecretKeyCA      = "ca.crt"
+)
+
+// secretClients the http clients with the certificates of the credentials Secrets by Secret. they are shared by
+// the reports of all runs in the process, so that the transport is reused until the Secret is changed.
+var secretClients = struct {
+	sync.Mutex
+	clients map[types.NamespacedName]secretClient
+}{clients: make(map[types.NamespacedName]secretClient)}
+
+type secretClient struct {
+	resourceVersion string
+	httpClient      *http.Client
+}
+
+// credentials to access kubesphere cloud.
+type credentials struct {
+	cloudID    string
+	token      string
+	httpClient *http.Client
+}
+
+// WithCredentialsSecret read cloudId, token and client certificates from the Secret.
+// the Secret is read on each Save, so that changes of the Secret are refreshed.
+func WithCredentialsSecret(ref types.NamespacedName) CloudOption {
+	return func(k *cloudReport) {
+		k.credentialsSecret = &ref
+	}
+}
+
+func (k *cloudReport) credentials(ctx context.Context) (*credentials, error) {
+	creds := &credentials{
+		cloudID:    k.cloudID,
+		httpClient: KSCloudClient,
+	}
+	if k.credentialsSecret == nil {
+		return creds, nil
+	}
+
+	secret := &corev1.Secret{}
+	if err := k.client.Get(ctx, *k.credentialsSecret, secret); err != nil {
+		return nil, fmt.Errorf("failed to get credentials secret %s: %w", k.credentialsSecret, err)
+	}
+	// the values created by kubectl create secret --from-file end with a newline.
+	if v, ok := secret.Data[SecretKeyCloudID]; ok {
+		creds.cloudID = strings.TrimSpace(string(v))
+	}
+	creds.token = strings.TrimSpace(string(secret.Data[SecretKeyToken]))
+
+	cert, key, ca := secret.Data[SecretKeyCert], secret.Data[SecretKeyKey], secret.Data[SecretKeyCA]
+	if len(cert) == 0 && len(ca) == 0 {
+		return creds, nil
+	}
+	// reuse the transport until the Secret is changed, so that the idle connections are not leaked.
+	secretClients.Lock()
+	defer secretClients.Unlock()
+	cached, ok := secretClients.clients[*k.credentialsSecret]
+	if ok && cached.resourceVersion == secret.ResourceVersion {
+		creds.httpClient = cached.httpClient
+		return creds, nil
+	}
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if len(cert) != 0 {
+		keyPair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate in secret %s: %w", k.credentialsSecret, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{keyPair}
+	}
+	if len(ca) != 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("invalid %s in secret %s", SecretKeyCA, k.credentialsSecret)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	if ok {
+		cached.httpClient.CloseIdleConnections()
+	}
+	creds.httpClient = newRateLimitedHTTPClient(transport)
+	secretClients.clients[*k.credentialsSecret] = secretClient{resourceVersion: secret.ResourceVersion, httpClient: creds.httpClient}
+	return creds, nil
+}
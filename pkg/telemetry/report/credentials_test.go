@@ -0,0 +1,99 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"kubesphere.io/telemetry/pkg/telemetry/collector"
+)
+
+// newCA returns a self-signed certificate in PEM.
+func newCA(t *testing.T) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ksCloud"},
+		NotBefore:             testNow,
+		NotAfter:              testNow.Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestCredentialsHTTPClientReused(t *testing.T) {
+	ref := types.NamespacedName{Namespace: "kubesphere-system", Name: "telemetry-credentials"}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: ref.Namespace, Name: ref.Name},
+		Data:       map[string][]byte{SecretKeyCloudID: []byte("cloud\n"), SecretKeyToken: []byte("token\n"), SecretKeyCA: newCA(t)},
+	}
+	client := fake.NewClientBuilder().WithScheme(collector.Schema).WithObjects(secret).Build()
+	ctx := context.Background()
+	// the report is created by each run
+	credentials := func() *credentials {
+		k := &cloudReport{client: client, credentialsSecret: &ref}
+		creds, err := k.credentials(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return creds
+	}
+
+	first := credentials()
+	if first.cloudID != "cloud" || first.token != "token" {
+		t.Errorf("cloudId, token = %q, %q, want cloud, token", first.cloudID, first.token)
+	}
+	if first.httpClient == KSCloudClient {
+		t.Error("the client without the ca of secret is used")
+	}
+	if second := credentials(); second.httpClient != first.httpClient {
+		t.Error("the client is not reused between runs when the secret is not changed")
+	}
+
+	if err := client.Get(ctx, ref, secret); err != nil {
+		t.Fatal(err)
+	}
+	secret.Data[SecretKeyCA] = newCA(t)
+	if err := client.Update(ctx, secret); err != nil {
+		t.Fatal(err)
+	}
+	if changed := credentials(); changed.httpClient == first.httpClient {
+		t.Error("the client is reused after the secret is changed")
+	}
+}
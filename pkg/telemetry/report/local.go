@@ -19,6 +19,7 @@ package report
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"time"
 
@@ -52,3 +53,16 @@ func (r localReport) Save(ctx context.Context, data map[string]any) error {
 	klog.Infof("Save data to local file in current dir success")
 	return nil
 }
+
+// ReadLocalReport returns the telemetry data in the file saved by local report.
+func ReadLocalReport(path string) (map[string]any, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data := make(map[string]any)
+	if err := json.Unmarshal(bs, &data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s: %w", path, err)
+	}
+	return data, nil
+}
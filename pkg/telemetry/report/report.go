@@ -17,7 +17,9 @@ limitations under the License.
 package report
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"net/http"
 
 	"golang.org/x/time/rate"
@@ -28,15 +30,38 @@ type Report interface {
 	Save(ctx context.Context, data map[string]any) error
 }
 
+// Run identifies a run of telemetry which collects the data.
+type Run struct {
+	// ID the unique id of the run.
+	ID string
+	// Collectors the record keys of the collectors in the run.
+	Collectors []string
+}
+
+type runKey struct{}
+
+// WithRun returns a copy of ctx which carries run.
+func WithRun(ctx context.Context, run Run) context.Context {
+	return context.WithValue(ctx, runKey{}, run)
+}
+
+// RunFrom returns the run carried by ctx.
+func RunFrom(ctx context.Context) (Run, bool) {
+	run, ok := ctx.Value(runKey{}).(Run)
+	return run, ok
+}
+
 // KSCloudClient rate limit http client to ksCloud
-var KSCloudClient = newRateLimitedHTTPClient(5, 10)
+var KSCloudClient = newRateLimitedHTTPClient(http.DefaultTransport)
 
-func newRateLimitedHTTPClient(rps int, burst int) *http.Client {
-	limiter := rate.NewLimiter(rate.Limit(rps), burst)
+// ksCloudRateLimiter is shared by all http clients to ksCloud.
+var ksCloudRateLimiter = rate.NewLimiter(rate.Limit(5), 10)
+
+func newRateLimitedHTTPClient(transport http.RoundTripper) *http.Client {
 	return &http.Client{
 		Transport: &rateLimitedTransport{
-			Transport:   http.DefaultTransport,
-			RateLimiter: limiter,
+			Transport:   transport,
+			RateLimiter: ksCloudRateLimiter,
 		},
 	}
 }
@@ -52,3 +77,31 @@ func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, err
 	}
 	return t.Transport.RoundTrip(req)
 }
+
+// CloseIdleConnections closes the idle connections of the underlying transport. see http.Client.CloseIdleConnections.
+func (t *rateLimitedTransport) CloseIdleConnections() {
+	if c, ok := t.Transport.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
+	}
+}
+
+// toMap converts v to the json map.
+func toMap(v any) (map[string]any, error) {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]any)
+	return m, json.Unmarshal(bs, &m)
+}
+
+// fromMap converts the json map to v. the fields unknown to v are rejected rather than dropped.
+func fromMap(m map[string]any, v any) error {
+	bs, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(bs))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
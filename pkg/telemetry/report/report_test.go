@@ -0,0 +1,64 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	telemetryv1alpha1 "kubesphere.io/telemetry/pkg/apis/telemetry/v1alpha1"
+	"kubesphere.io/telemetry/pkg/telemetry/collector"
+)
+
+func TestFromMap(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    map[string]any
+		wantErr bool
+	}{
+		{
+			name: "known",
+			data: map[string]any{"ts": "2024-05-01T00:00:00Z", "clusters": []any{map[string]any{"name": "host", "role": "host"}}},
+		},
+		{name: "unknown", data: map[string]any{"ts": "2024-05-01T00:00:00Z", "unknown": 1}, wantErr: true},
+		{name: "unknown nested", data: map[string]any{"clusters": []any{map[string]any{"name": "host", "unknown": 1}}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := telemetryv1alpha1.ClusterInfoStatus{}
+			if err := fromMap(tt.data, &status); (err != nil) != tt.wantErr {
+				t.Errorf("fromMap() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// the data of each collector is saved in the status of ClusterInfo, which rejects unknown fields.
+func TestCollectorsInStatus(t *testing.T) {
+	fields := make(map[string]bool)
+	statusType := reflect.TypeOf(telemetryv1alpha1.ClusterInfoStatus{})
+	for i := 0; i < statusType.NumField(); i++ {
+		name, _, _ := strings.Cut(statusType.Field(i).Tag.Get("json"), ",")
+		fields[name] = true
+	}
+	for _, c := range collector.Registered {
+		if !fields[c.RecordKey()] {
+			t.Errorf("the data of collector %s is not a field of ClusterInfo status", c.RecordKey())
+		}
+	}
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	telemetryv1alpha1 "kubesphere.io/telemetry/pkg/apis/telemetry/v1alpha1"
+)
+
+// PruneReason why a ClusterInfo is pruned.
+type PruneReason string
+
+const (
+	// PruneReasonAge the ClusterInfo is older than the max age.
+	PruneReasonAge PruneReason = "Age"
+	// PruneReasonCount there are more ClusterInfo than the max count.
+	PruneReasonCount PruneReason = "Count"
+	// PruneReasonSize the total size of ClusterInfo exceeds the max size.
+	PruneReasonSize PruneReason = "Size"
+)
+
+// RetentionPolicy decides which ClusterInfo are pruned. the zero limits mean no limit.
+// the latest ClusterInfo is always kept.
+type RetentionPolicy struct {
+	// MaxAge prune the ClusterInfo created longer ago.
+	MaxAge time.Duration
+	// MaxCount the max number of ClusterInfo. the oldest are pruned first.
+	MaxCount int
+	// MaxSize the max total size in bytes of the status of ClusterInfo stored in etcd. the oldest are pruned first.
+	MaxSize int64
+	// PruneUnsynced whether the ClusterInfo which is not synced to ksCloud can be pruned.
+	// the ClusterInfo which is permanently failed to sync is always prunable.
+	PruneUnsynced bool
+}
+
+// Pruned a ClusterInfo to prune and why.
+type Pruned struct {
+	ClusterInfo telemetryv1alpha1.ClusterInfo
+	Reason      PruneReason
+}
+
+// PruneSummary what is pruned by RetentionPolicy and why.
+type PruneSummary struct {
+	Pruned []Pruned
+	// Kept the number of ClusterInfo which are kept.
+	Kept int
+	// KeptSize the total size in bytes of the ClusterInfo which are kept.
+	KeptSize int64
+	// KeptUnsynced the number of ClusterInfo which exceed the limits but are kept since they are not synced.
+	KeptUnsynced int
+}
+
+// Count returns the number of ClusterInfo pruned by reason.
+func (s PruneSummary) Count(reason PruneReason) int {
+	var n int
+	for _, p := range s.Pruned {
+		if p.Reason == reason {
+			n++
+		}
+	}
+	return n
+}
+
+func (s PruneSummary) String() string {
+	return fmt.Sprintf("pruned %d clusterInfo (age: %d, count: %d, size: %d), kept %d clusterInfo of %d bytes, kept %d unsynced clusterInfo exceeding the limits",
+		len(s.Pruned), s.Count(PruneReasonAge), s.Count(PruneReasonCount), s.Count(PruneReasonSize), s.Kept, s.KeptSize, s.KeptUnsynced)
+}
+
+// Prune returns the ClusterInfo to prune. clusterInfos are sorted by the time of collection, see ListClusterInfos.
+func (p RetentionPolicy) Prune(clusterInfos []telemetryv1alpha1.ClusterInfo, now time.Time) PruneSummary {
+	count := len(clusterInfos)
+	var size int64
+	sizes := make([]int64, len(clusterInfos))
+	for i := range clusterInfos {
+		sizes[i] = StatusSize(&clusterInfos[i])
+		size += sizes[i]
+	}
+	var summary PruneSummary
+	for i := range clusterInfos {
+		clusterInfo := &clusterInfos[i]
+		var reason PruneReason
+		switch {
+		case i == len(clusterInfos)-1: // keep the latest
+		case p.MaxAge > 0 && clusterInfo.CreationTimestamp.Add(p.MaxAge).Before(now):
+			reason = PruneReasonAge
+		case p.MaxCount > 0 && count > p.MaxCount:
+			reason = PruneReasonCount
+		case p.MaxSize > 0 && size > p.MaxSize:
+			reason = PruneReasonSize
+		}
+		if reason == "" {
+			continue
+		}
+		if !p.PruneUnsynced && isUnsynced(clusterInfo) {
+			summary.KeptUnsynced++
+			continue
+		}
+		summary.Pruned = append(summary.Pruned, Pruned{ClusterInfo: *clusterInfo, Reason: reason})
+		count--
+		size -= sizes[i]
+	}
+	summary.Kept = count
+	summary.KeptSize = size
+	return summary
+}
+
+// isUnsynced reports whether clusterInfo is waiting to sync to ksCloud.
+func isUnsynced(clusterInfo *telemetryv1alpha1.ClusterInfo) bool {
+	return clusterInfo.Status.SyncTime == nil && !IsSyncPermanentlyFailed(clusterInfo)
+}
+
+// StatusSize returns the size in bytes of the json encoded status.
+func StatusSize(clusterInfo *telemetryv1alpha1.ClusterInfo) int64 {
+	data, err := json.Marshal(clusterInfo.Status)
+	if err != nil {
+		return 0
+	}
+	return int64(len(data))
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	telemetryv1alpha1 "kubesphere.io/telemetry/pkg/apis/telemetry/v1alpha1"
+)
+
+var testNow = time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC)
+
+// sync states of the ClusterInfo in tests
+const (
+	synced   = "synced"
+	unsynced = "unsynced"
+	failed   = "failed"
+)
+
+// newClusterInfo returns a ClusterInfo created age ago in state.
+func newClusterInfo(name string, age time.Duration, state string) telemetryv1alpha1.ClusterInfo {
+	created := metav1.NewTime(testNow.Add(-age))
+	clusterInfo := telemetryv1alpha1.ClusterInfo{
+		ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: created},
+		Status:     telemetryv1alpha1.ClusterInfoStatus{TS: &created},
+	}
+	switch state {
+	case synced:
+		clusterInfo.Status.SyncTime = &metav1.Time{Time: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	case failed:
+		clusterInfo.Status.Conditions = []metav1.Condition{{
+			Type:   telemetryv1alpha1.ConditionTypeSyncFailed,
+			Status: metav1.ConditionTrue,
+			Reason: reasonPermanentFailure,
+		}}
+	}
+	return clusterInfo
+}
+
+func TestRetentionPolicyPrune(t *testing.T) {
+	day := 24 * time.Hour
+	size := StatusSize(ptr.To(newClusterInfo("", 0, synced)))
+	tests := []struct {
+		name         string
+		policy       RetentionPolicy
+		clusterInfos []telemetryv1alpha1.ClusterInfo
+		// wantPruned the name and the reason of the pruned ClusterInfo
+		wantPruned       map[string]PruneReason
+		wantKeptUnsynced int
+	}{
+		{
+			name:   "no limit",
+			policy: RetentionPolicy{},
+			clusterInfos: []telemetryv1alpha1.ClusterInfo{
+				newClusterInfo("a", 3*day, synced), newClusterInfo("b", 2*day, synced),
+			},
+		},
+		{
+			name:   "age",
+			policy: RetentionPolicy{MaxAge: 2 * day},
+			clusterInfos: []telemetryv1alpha1.ClusterInfo{
+				newClusterInfo("a", 4*day, synced), newClusterInfo("b", 3*day, synced), newClusterInfo("c", day, synced),
+			},
+			wantPruned: map[string]PruneReason{"a": PruneReasonAge, "b": PruneReasonAge},
+		},
+		{
+			name:   "the latest is always kept",
+			policy: RetentionPolicy{MaxAge: day, MaxCount: 1, MaxSize: 1},
+			clusterInfos: []telemetryv1alpha1.ClusterInfo{
+				newClusterInfo("a", 4*day, synced), newClusterInfo("b", 3*day, synced),
+			},
+			wantPruned: map[string]PruneReason{"a": PruneReasonAge},
+		},
+		{
+			name:   "count",
+			policy: RetentionPolicy{MaxCount: 2},
+			clusterInfos: []telemetryv1alpha1.ClusterInfo{
+				newClusterInfo("a", 4*day, synced), newClusterInfo("b", 3*day, synced),
+				newClusterInfo("c", 2*day, synced), newClusterInfo("d", day, synced),
+			},
+			wantPruned: map[string]PruneReason{"a": PruneReasonCount, "b": PruneReasonCount},
+		},
+		{
+			name:   "size",
+			policy: RetentionPolicy{MaxSize: 2 * size},
+			clusterInfos: []telemetryv1alpha1.ClusterInfo{
+				newClusterInfo("a", 4*day, synced), newClusterInfo("b", 3*day, synced), newClusterInfo("c", 2*day, synced),
+			},
+			wantPruned: map[string]PruneReason{"a": PruneReasonSize},
+		},
+		{
+			name:   "age before count",
+			policy: RetentionPolicy{MaxAge: 3 * day, MaxCount: 2},
+			clusterInfos: []telemetryv1alpha1.ClusterInfo{
+				newClusterInfo("a", 4*day, synced), newClusterInfo("b", 2*day, synced),
+				newClusterInfo("c", 2*day, synced), newClusterInfo("d", day, synced),
+			},
+			wantPruned: map[string]PruneReason{"a": PruneReasonAge, "b": PruneReasonCount},
+		},
+		{
+			name:   "unsynced are kept",
+			policy: RetentionPolicy{MaxAge: 2 * day, MaxCount: 1, MaxSize: 1},
+			clusterInfos: []telemetryv1alpha1.ClusterInfo{
+				newClusterInfo("a", 4*day, unsynced), newClusterInfo("b", 3*day, synced),
+				newClusterInfo("c", 2*day, unsynced), newClusterInfo("d", day, synced),
+			},
+			wantPruned:       map[string]PruneReason{"b": PruneReasonAge},
+			wantKeptUnsynced: 2,
+		},
+		{
+			name:   "unsynced are pruned with PruneUnsynced",
+			policy: RetentionPolicy{MaxAge: 2 * day, PruneUnsynced: true},
+			clusterInfos: []telemetryv1alpha1.ClusterInfo{
+				newClusterInfo("a", 4*day, unsynced), newClusterInfo("b", day, unsynced),
+			},
+			wantPruned: map[string]PruneReason{"a": PruneReasonAge},
+		},
+		{
+			name:   "permanently failed are prunable",
+			policy: RetentionPolicy{MaxCount: 1},
+			clusterInfos: []telemetryv1alpha1.ClusterInfo{
+				newClusterInfo("a", 4*day, failed), newClusterInfo("b", 3*day, unsynced), newClusterInfo("c", day, synced),
+			},
+			wantPruned:       map[string]PruneReason{"a": PruneReasonCount},
+			wantKeptUnsynced: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary := tt.policy.Prune(tt.clusterInfos, testNow)
+			pruned := make(map[string]PruneReason)
+			for _, p := range summary.Pruned {
+				pruned[p.ClusterInfo.Name] = p.Reason
+			}
+			if len(pruned) != 0 || len(tt.wantPruned) != 0 {
+				if !reflect.DeepEqual(pruned, tt.wantPruned) {
+					t.Errorf("pruned = %v, want %v", pruned, tt.wantPruned)
+				}
+			}
+			var kept int
+			var keptSize int64
+			for i := range tt.clusterInfos {
+				if _, ok := pruned[tt.clusterInfos[i].Name]; !ok {
+					kept++
+					keptSize += StatusSize(&tt.clusterInfos[i])
+				}
+			}
+			if summary.Kept != kept || summary.KeptSize != keptSize {
+				t.Errorf("kept = %d of %d bytes, want %d of %d bytes", summary.Kept, summary.KeptSize, kept, keptSize)
+			}
+			if summary.KeptUnsynced != tt.wantKeptUnsynced {
+				t.Errorf("keptUnsynced = %d, want %d", summary.KeptUnsynced, tt.wantKeptUnsynced)
+			}
+		})
+	}
+}
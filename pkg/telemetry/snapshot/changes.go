@@ -0,0 +1,144 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snapshot
+
+import (
+	"sort"
+	"strconv"
+
+	telemetryv1alpha1 "kubesphere.io/telemetry/pkg/apis/telemetry/v1alpha1"
+)
+
+const (
+	EventClusterJoined      = "ClusterJoined"
+	EventClusterLeft        = "ClusterLeft"
+	EventKubeSphereUpgraded = "KubeSphereUpgraded"
+	EventKubernetesUpgraded = "KubernetesUpgraded"
+	EventNodesChanged       = "NodesChanged"
+)
+
+type (
+	Changes          = telemetryv1alpha1.Changes
+	ClusterLifecycle = telemetryv1alpha1.ClusterLifecycle
+	Event            = telemetryv1alpha1.ChangeEvent
+)
+
+// ComputeChanges computes the changes of current relative to history, which are the snapshots before current.
+// the lifecycles of clusters are carried forward from the changes of the previous snapshot, so that they are kept
+// when the oldest snapshots are deleted by the history retention or compaction.
+func ComputeChanges(history []*Snapshot, current *Snapshot) *Changes {
+	all := append(append(make([]*Snapshot, 0, len(history)+1), history...), current)
+	Sort(all)
+
+	changes := &Changes{
+		Clusters: make([]ClusterLifecycle, 0),
+		Events:   make([]Event, 0),
+	}
+	var previous *Snapshot
+	for _, s := range all {
+		if s == current {
+			break
+		}
+		previous = s
+	}
+
+	lifecycles := make(map[string]*ClusterLifecycle)
+	var keys []string
+	if previous != nil && previous.Changes != nil {
+		for _, l := range previous.Changes.Clusters {
+			key := clusterKey(telemetryv1alpha1.Cluster{Name: l.Name, Uid: l.Uid})
+			if _, ok := lifecycles[key]; ok {
+				continue
+			}
+			l.NodeDelta = 0
+			lifecycles[key] = &l
+			keys = append(keys, key)
+		}
+	}
+	for _, s := range all {
+		for _, cluster := range s.Clusters {
+			key := clusterKey(cluster)
+			l, ok := lifecycles[key]
+			if !ok {
+				l = &ClusterLifecycle{Name: cluster.Name, Uid: cluster.Uid, FirstSeen: s.TS}
+				lifecycles[key] = l
+				keys = append(keys, key)
+			}
+			if l.FirstSeen == "" || s.TS < l.FirstSeen {
+				l.FirstSeen = s.TS
+			}
+			if s.TS > l.LastSeen {
+				l.LastSeen = s.TS
+			}
+		}
+	}
+
+	if previous != nil {
+		changes.Since = previous.TS
+		changes.Events = clusterEvents(previous, current)
+		previousNodes := make(map[string]int)
+		for _, cluster := range previous.Clusters {
+			previousNodes[clusterKey(cluster)] = len(cluster.Nodes)
+		}
+		for _, cluster := range current.Clusters {
+			if n, ok := previousNodes[clusterKey(cluster)]; ok {
+				lifecycles[clusterKey(cluster)].NodeDelta = len(cluster.Nodes) - n
+			}
+		}
+	}
+	for _, key := range keys {
+		changes.Clusters = append(changes.Clusters, *lifecycles[key])
+	}
+	sort.SliceStable(changes.Clusters, func(i, j int) bool {
+		return changes.Clusters[i].Name < changes.Clusters[j].Name
+	})
+	return changes
+}
+
+// clusterEvents returns the join, leave, upgrade and node change events of clusters from a to b.
+func clusterEvents(a, b *Snapshot) []Event {
+	events := make([]Event, 0)
+	clustersA := make(map[string]int)
+	for i, cluster := range a.Clusters {
+		clustersA[clusterKey(cluster)] = i
+	}
+	clustersB := make(map[string]bool)
+	for _, cluster := range b.Clusters {
+		clustersB[clusterKey(cluster)] = true
+		i, ok := clustersA[clusterKey(cluster)]
+		if !ok {
+			events = append(events, Event{Type: EventClusterJoined, Cluster: cluster.Name})
+			continue
+		}
+		old := a.Clusters[i]
+		if from, to := GitVersion(old.KSVersion), GitVersion(cluster.KSVersion); from != to {
+			events = append(events, Event{Type: EventKubeSphereUpgraded, Cluster: cluster.Name, From: from, To: to})
+		}
+		if from, to := GitVersion(old.ClusterVersion), GitVersion(cluster.ClusterVersion); from != to {
+			events = append(events, Event{Type: EventKubernetesUpgraded, Cluster: cluster.Name, From: from, To: to})
+		}
+		if len(old.Nodes) != len(cluster.Nodes) {
+			events = append(events, Event{Type: EventNodesChanged, Cluster: cluster.Name, From: strconv.Itoa(len(old.Nodes)), To: strconv.Itoa(len(cluster.Nodes))})
+		}
+	}
+	for _, cluster := range a.Clusters {
+		if !clustersB[clusterKey(cluster)] {
+			events = append(events, Event{Type: EventClusterLeft, Cluster: cluster.Name})
+		}
+	}
+	return events
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snapshot
+
+import (
+	"testing"
+
+	telemetryv1alpha1 "kubesphere.io/telemetry/pkg/apis/telemetry/v1alpha1"
+)
+
+func TestComputeChangesFirstSeen(t *testing.T) {
+	host := telemetryv1alpha1.Cluster{Name: "host", Uid: "1"}
+	member := telemetryv1alpha1.Cluster{Name: "member", Uid: "2"}
+	left := telemetryv1alpha1.Cluster{Name: "left", Uid: "3"}
+	// each snapshot is saved with the changes computed from the snapshots before it
+	var history []*Snapshot
+	for _, s := range []*Snapshot{
+		{TS: "2024-05-01T00:00:00Z", Clusters: []telemetryv1alpha1.Cluster{host, left}},
+		{TS: "2024-05-02T00:00:00Z", Clusters: []telemetryv1alpha1.Cluster{host, member}},
+		{TS: "2024-05-03T00:00:00Z", Clusters: []telemetryv1alpha1.Cluster{host, member}},
+	} {
+		s.Changes = ComputeChanges(history, s)
+		history = append(history, s)
+	}
+
+	tests := []struct {
+		name    string
+		history []*Snapshot
+	}{
+		{name: "all snapshots", history: history},
+		{name: "the oldest snapshot is pruned", history: history[1:]},
+		{name: "only the previous snapshot", history: history[2:]},
+	}
+	want := map[string]ClusterLifecycle{
+		"host":   {Name: "host", Uid: "1", FirstSeen: "2024-05-01T00:00:00Z", LastSeen: "2024-05-04T00:00:00Z"},
+		"member": {Name: "member", Uid: "2", FirstSeen: "2024-05-02T00:00:00Z", LastSeen: "2024-05-04T00:00:00Z"},
+		"left":   {Name: "left", Uid: "3", FirstSeen: "2024-05-01T00:00:00Z", LastSeen: "2024-05-01T00:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := &Snapshot{TS: "2024-05-04T00:00:00Z", Clusters: []telemetryv1alpha1.Cluster{host, member}}
+			changes := ComputeChanges(tt.history, current)
+			if len(changes.Clusters) != len(want) {
+				t.Fatalf("clusters = %+v, want %d clusters", changes.Clusters, len(want))
+			}
+			for _, got := range changes.Clusters {
+				if got != want[got.Name] {
+					t.Errorf("cluster %s = %+v, want %+v", got.Name, got, want[got.Name])
+				}
+			}
+		})
+	}
+}
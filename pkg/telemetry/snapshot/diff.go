@@ -0,0 +1,207 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snapshot
+
+import (
+	"sort"
+
+	telemetryv1alpha1 "kubesphere.io/telemetry/pkg/apis/telemetry/v1alpha1"
+)
+
+// Diff what changed from snapshot a to snapshot b.
+type Diff struct {
+	From            string        `json:"from"`
+	To              string        `json:"to"`
+	ClustersAdded   []string      `json:"clustersAdded"`
+	ClustersRemoved []string      `json:"clustersRemoved"`
+	Clusters        []ClusterDiff `json:"clusters"`
+	Extensions      ExtensionDiff `json:"extensions"`
+	// Counts deltas of platform counts
+	Counts []CountDelta `json:"counts"`
+}
+
+// ClusterDiff what changed in a cluster which exists in both snapshots.
+type ClusterDiff struct {
+	Name           string       `json:"name"`
+	KSVersion      *Change      `json:"ksVersion,omitempty"`
+	ClusterVersion *Change      `json:"clusterVersion,omitempty"`
+	NodesAdded     []string     `json:"nodesAdded"`
+	NodesRemoved   []string     `json:"nodesRemoved"`
+	Counts         []CountDelta `json:"counts"`
+}
+
+type ExtensionDiff struct {
+	Added   []ExtensionChange `json:"added"`
+	Removed []ExtensionChange `json:"removed"`
+	Changed []ExtensionChange `json:"changed"`
+}
+
+type ExtensionChange struct {
+	Name    string  `json:"name"`
+	Version *Change `json:"version,omitempty"`
+	State   *Change `json:"state,omitempty"`
+}
+
+type Change struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type CountDelta struct {
+	Name  string `json:"name"`
+	From  int    `json:"from"`
+	To    int    `json:"to"`
+	Delta int    `json:"delta"`
+}
+
+// Empty returns true when nothing changed.
+func (d ClusterDiff) Empty() bool {
+	return d.KSVersion == nil && d.ClusterVersion == nil && len(d.NodesAdded) == 0 && len(d.NodesRemoved) == 0 && len(d.Counts) == 0
+}
+
+// ComputeDiff computes what changed from a to b.
+func ComputeDiff(a, b *Snapshot) *Diff {
+	d := &Diff{
+		From:            a.TS,
+		To:              b.TS,
+		ClustersAdded:   make([]string, 0),
+		ClustersRemoved: make([]string, 0),
+		Clusters:        make([]ClusterDiff, 0),
+		Extensions: ExtensionDiff{
+			Added:   make([]ExtensionChange, 0),
+			Removed: make([]ExtensionChange, 0),
+			Changed: make([]ExtensionChange, 0),
+		},
+		Counts: make([]CountDelta, 0),
+	}
+
+	clustersA := make(map[string]telemetryv1alpha1.Cluster)
+	for _, cluster := range a.Clusters {
+		clustersA[clusterKey(cluster)] = cluster
+	}
+	clustersB := make(map[string]bool)
+	for _, cluster := range b.Clusters {
+		clustersB[clusterKey(cluster)] = true
+		old, ok := clustersA[clusterKey(cluster)]
+		if !ok {
+			d.ClustersAdded = append(d.ClustersAdded, cluster.Name)
+			continue
+		}
+		if cd := diffCluster(old, cluster, workloadOf(a, old.Name), workloadOf(b, cluster.Name)); !cd.Empty() {
+			d.Clusters = append(d.Clusters, cd)
+		}
+	}
+	for _, cluster := range a.Clusters {
+		if !clustersB[clusterKey(cluster)] {
+			d.ClustersRemoved = append(d.ClustersRemoved, cluster.Name)
+		}
+	}
+	sort.Strings(d.ClustersAdded)
+	sort.Strings(d.ClustersRemoved)
+	sort.Slice(d.Clusters, func(i, j int) bool { return d.Clusters[i].Name < d.Clusters[j].Name })
+
+	extensionsA := make(map[string]telemetryv1alpha1.Extension)
+	for _, ext := range a.Extension {
+		extensionsA[ext.Name] = ext
+	}
+	extensionsB := make(map[string]bool)
+	for _, ext := range b.Extension {
+		extensionsB[ext.Name] = true
+		old, ok := extensionsA[ext.Name]
+		if !ok {
+			d.Extensions.Added = append(d.Extensions.Added, ExtensionChange{Name: ext.Name, Version: &Change{To: ext.Version}, State: &Change{To: ext.State}})
+			continue
+		}
+		ec := ExtensionChange{Name: ext.Name, Version: newChange(old.Version, ext.Version), State: newChange(old.State, ext.State)}
+		if ec.Version != nil || ec.State != nil {
+			d.Extensions.Changed = append(d.Extensions.Changed, ec)
+		}
+	}
+	for _, ext := range a.Extension {
+		if !extensionsB[ext.Name] {
+			d.Extensions.Removed = append(d.Extensions.Removed, ExtensionChange{Name: ext.Name, Version: &Change{From: ext.Version}, State: &Change{From: ext.State}})
+		}
+	}
+
+	d.Counts = appendCountDelta(d.Counts, "workspace", a.Platform.Workspace, b.Platform.Workspace)
+	d.Counts = appendCountDelta(d.Counts, "user", a.Platform.User, b.Platform.User)
+	d.Counts = appendCountDelta(d.Counts, "cluster", len(a.Clusters), len(b.Clusters))
+	d.Counts = appendCountDelta(d.Counts, "extension", len(a.Extension), len(b.Extension))
+	return d
+}
+
+func diffCluster(a, b telemetryv1alpha1.Cluster, workloadA, workloadB telemetryv1alpha1.Workload) ClusterDiff {
+	cd := ClusterDiff{
+		Name:           b.Name,
+		KSVersion:      newChange(GitVersion(a.KSVersion), GitVersion(b.KSVersion)),
+		ClusterVersion: newChange(GitVersion(a.ClusterVersion), GitVersion(b.ClusterVersion)),
+		NodesAdded:     make([]string, 0),
+		NodesRemoved:   make([]string, 0),
+		Counts:         make([]CountDelta, 0),
+	}
+	nodesA := make(map[string]bool)
+	for _, node := range a.Nodes {
+		nodesA[node.Uid] = true
+	}
+	nodesB := make(map[string]bool)
+	for _, node := range b.Nodes {
+		nodesB[node.Uid] = true
+		if !nodesA[node.Uid] {
+			cd.NodesAdded = append(cd.NodesAdded, node.Name)
+		}
+	}
+	for _, node := range a.Nodes {
+		if !nodesB[node.Uid] {
+			cd.NodesRemoved = append(cd.NodesRemoved, node.Name)
+		}
+	}
+	sort.Strings(cd.NodesAdded)
+	sort.Strings(cd.NodesRemoved)
+
+	cd.Counts = appendCountDelta(cd.Counts, "node", len(a.Nodes), len(b.Nodes))
+	cd.Counts = appendCountDelta(cd.Counts, "namespace", a.Namespace, b.Namespace)
+	cd.Counts = appendCountDelta(cd.Counts, "deployment", workloadA.Deployment, workloadB.Deployment)
+	cd.Counts = appendCountDelta(cd.Counts, "statefulSet", workloadA.StatefulSet, workloadB.StatefulSet)
+	cd.Counts = appendCountDelta(cd.Counts, "daemonSet", workloadA.DaemonSet, workloadB.DaemonSet)
+	cd.Counts = appendCountDelta(cd.Counts, "job", workloadA.Job, workloadB.Job)
+	cd.Counts = appendCountDelta(cd.Counts, "cronJob", workloadA.CronJob, workloadB.CronJob)
+	return cd
+}
+
+func workloadOf(s *Snapshot, cluster string) telemetryv1alpha1.Workload {
+	for _, w := range s.Workloads {
+		if w.Cluster == cluster {
+			return w
+		}
+	}
+	return telemetryv1alpha1.Workload{}
+}
+
+func newChange(from, to string) *Change {
+	if from == to {
+		return nil
+	}
+	return &Change{From: from, To: to}
+}
+
+// appendCountDelta appends the delta to deltas when the count changed.
+func appendCountDelta(deltas []CountDelta, name string, from, to int) []CountDelta {
+	if from == to {
+		return deltas
+	}
+	return append(deltas, CountDelta{Name: name, From: from, To: to, Delta: to - from})
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snapshot
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	telemetryv1alpha1 "kubesphere.io/telemetry/pkg/apis/telemetry/v1alpha1"
+)
+
+// Snapshot the telemetry data collected in one run.
+type Snapshot struct {
+	TS        string                        `json:"ts"`
+	Clusters  []telemetryv1alpha1.Cluster   `json:"clusters"`
+	Extension []telemetryv1alpha1.Extension `json:"extension"`
+	Platform  telemetryv1alpha1.Platform    `json:"platform"`
+	Workloads []telemetryv1alpha1.Workload  `json:"workloads"`
+	// Changes computed when the snapshot is saved. it's nil for the snapshot which is being collected.
+	Changes *telemetryv1alpha1.Changes `json:"changes,omitempty"`
+}
+
+// Decode converts the telemetry data or the status of ClusterInfo to Snapshot.
+func Decode(data any) (*Snapshot, error) {
+	bs, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	s := &Snapshot{}
+	return s, json.Unmarshal(bs, s)
+}
+
+// Sort sorts snapshots by ts in ascending order.
+func Sort(snapshots []*Snapshot) {
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].TS < snapshots[j].TS
+	})
+}
+
+// clusterKey identifies a cluster across snapshots. the name of cluster may be reused after it's deleted.
+func clusterKey(cluster telemetryv1alpha1.Cluster) string {
+	if cluster.Uid != "" {
+		return cluster.Uid
+	}
+	return cluster.Name
+}
+
+// GitVersion returns the gitVersion of ksVersion or clusterVersion, which is a json string
+// like {"gitVersion":"v4.1.0",...}, or the version itself when it's not collected from ks-apiserver.
+func GitVersion(v string) string {
+	if !strings.HasPrefix(v, "{") {
+		return v
+	}
+	version := struct {
+		GitVersion string `json:"gitVersion"`
+	}{}
+	if err := json.Unmarshal([]byte(v), &version); err != nil {
+		return v
+	}
+	return version.GitVersion
+}
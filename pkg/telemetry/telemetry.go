@@ -19,10 +19,18 @@ package telemetry
 import (
 	"context"
 	"encoding/json"
+	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/sync/errgroup"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -41,10 +49,28 @@ func NewTelemetry(opts ...Option) manager.Runnable {
 	return t
 }
 
+const (
+	// event reasons of telemetry
+	reasonCollected      = "Collected"
+	reasonCollectFailed  = "CollectFailed"
+	reasonCollectSkipped = "CollectSkipped"
+)
+
+// Resources returns the resources which telemetry accesses in every mode besides the collectors and the report.
+func Resources() []collector.Resource {
+	return []collector.Resource{
+		// events of collection on the pod and events of ClusterInfo
+		{Resource: "events", Verbs: []string{collector.VerbCreate, collector.VerbPatch}},
+	}
+}
+
 type telemetry struct {
 	config     *rest.Config
 	collectors []collector.Collector
 	report     report.Report
+	recorder   record.EventRecorder
+	// object the events of collection are recorded on. e.g. the pod which runs telemetry.
+	object runtime.Object
 }
 
 func (t *telemetry) RegisterCollector(cs ...collector.Collector) {
@@ -68,6 +94,21 @@ func WithReport(report report.Report) Option {
 	}
 }
 
+// WithEventRecorder set the recorder to record the events of collection on object.
+func WithEventRecorder(recorder record.EventRecorder, object runtime.Object) Option {
+	return func(t *telemetry) {
+		t.recorder = recorder
+		t.object = object
+	}
+}
+
+func (t *telemetry) eventf(eventtype, reason, messageFmt string, args ...any) {
+	if t.recorder == nil || t.object == nil {
+		return
+	}
+	t.recorder.Eventf(t.object, eventtype, reason, messageFmt, args...)
+}
+
 func (t *telemetry) Start(ctx context.Context) error {
 	cli, err := runtimeclient.New(t.config, runtimeclient.Options{
 		Scheme: collector.Schema,
@@ -75,31 +116,84 @@ func (t *telemetry) Start(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	collectors := t.availableCollectors()
+	run := report.Run{ID: string(uuid.NewUUID())}
+	for _, c := range collectors {
+		run.Collectors = append(run.Collectors, c.RecordKey())
+	}
+	data, err := t.collect(ctx, cli, collectors)
+	if err != nil {
+		return err
+	}
+	t.eventf(corev1.EventTypeNormal, reasonCollected, "collected data by %d collectors", len(collectors))
+	dataMap, err := serializeMap(data)
+	if err != nil {
+		klog.Errorf("failed to serializeMap %v", err)
+	}
+	return t.report.Save(report.WithRun(ctx, run), dataMap)
+}
+
+// collect runs collectors concurrently except the collectors of CostHigh, which run one at a time
+// so that the apiservers are not listed heavily at the same time.
+func (t *telemetry) collect(ctx context.Context, cli runtimeclient.Client, collectors []collector.Collector) (map[string]any, error) {
 	var data = make(map[string]interface{})
 	data["ts"] = time.Now().UTC().Format(time.RFC3339)
 	//var wg wait.Group
 	var wg errgroup.Group
-	for _, c := range t.collectors {
+	var mu, costHigh sync.Mutex
+	for _, c := range collectors {
 		lc := c
 		wg.Go(func() error {
+			requirements := collector.RequirementsOf(lc)
+			if requirements.Cost == collector.CostHigh {
+				costHigh.Lock()
+				defer costHigh.Unlock()
+			}
+			klog.V(4).Infof("collector %s collect data. scope: %s, cost: %s", lc.RecordKey(), requirements.Scope, requirements.Cost)
 			value, err := lc.Collect(ctx, cli)
 			if err != nil {
 				// retry
 				klog.Errorf("collector %s collect data error %v", lc.RecordKey(), err)
+				t.eventf(corev1.EventTypeWarning, reasonCollectFailed, "collector %s collect data error %v", lc.RecordKey(), err)
 				return err
 			}
+			mu.Lock()
+			defer mu.Unlock()
 			data[lc.RecordKey()] = value
 			return nil
 		})
 	}
-	if err := wg.Wait(); err != nil {
-		return err
+	return data, wg.Wait()
+}
+
+// availableCollectors returns the collectors which resources are served by the host cluster.
+// the collectors are skipped rather than failed when their CRDs are not installed. e.g. kubesphere is not installed.
+func (t *telemetry) availableCollectors() []collector.Collector {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(t.config)
+	if err != nil {
+		klog.Warningf("create discovery client error %v. no collector is skipped", err)
+		return t.collectors
 	}
-	dataMap, err := serializeMap(data)
+	served, err := collector.DiscoverResources(discoveryClient)
 	if err != nil {
-		klog.Errorf("failed to serializeMap %v", err)
+		klog.Warningf("discover resources error %v. no collector is skipped", err)
+		return t.collectors
+	}
+	collectors := make([]collector.Collector, 0, len(t.collectors))
+	for _, c := range t.collectors {
+		missing := served.Missing(collector.RequirementsOf(c))
+		if len(missing) == 0 {
+			collectors = append(collectors, c)
+			continue
+		}
+		names := make([]string, len(missing))
+		for i, r := range missing {
+			names[i] = schema.GroupResource{Group: r.Group, Resource: r.Resource}.String()
+		}
+		klog.Infof("skip collector %s. resources %s are not served", c.RecordKey(), strings.Join(names, ", "))
+		t.eventf(corev1.EventTypeNormal, reasonCollectSkipped, "skip collector %s. resources %s are not served", c.RecordKey(), strings.Join(names, ", "))
 	}
-	return t.report.Save(ctx, dataMap)
+	return collectors
 }
 
 func serializeMap(data map[string]any) (map[string]any, error) {
@@ -0,0 +1,107 @@
+/*
+Copyright 2024 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"kubesphere.io/telemetry/pkg/telemetry/collector"
+)
+
+// costCollector records how many collectors of CostHigh are collecting at the same time.
+type costCollector struct {
+	key     string
+	cost    collector.Cost
+	err     error
+	running *atomic.Int32
+	maxHigh *atomic.Int32
+}
+
+func (c *costCollector) RecordKey() string {
+	return c.key
+}
+
+func (c *costCollector) Requirements() collector.Requirements {
+	return collector.Requirements{Cost: c.cost}
+}
+
+func (c *costCollector) Collect(ctx context.Context, client runtimeclient.Client) (interface{}, error) {
+	if c.cost == collector.CostHigh {
+		n := c.running.Add(1)
+		defer c.running.Add(-1)
+		for {
+			old := c.maxHigh.Load()
+			if n <= old || c.maxHigh.CompareAndSwap(old, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return c.key, c.err
+}
+
+func TestCollect(t *testing.T) {
+	tests := []struct {
+		name  string
+		costs []collector.Cost
+		err   error
+	}{
+		{
+			name:  "high cost one at a time",
+			costs: []collector.Cost{collector.CostHigh, collector.CostLow, collector.CostHigh, collector.CostMedium, collector.CostHigh, ""},
+		},
+		{
+			name:  "failed",
+			costs: []collector.Cost{collector.CostHigh, collector.CostLow},
+			err:   errors.New("failed"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var running, maxHigh atomic.Int32
+			var collectors []collector.Collector
+			for i, cost := range tt.costs {
+				collectors = append(collectors, &costCollector{key: fmt.Sprintf("c%d", i), cost: cost, err: tt.err, running: &running, maxHigh: &maxHigh})
+			}
+			data, err := (&telemetry{}).collect(context.Background(), nil, collectors)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("collect() error = %v, want %v", err, tt.err)
+			}
+			if got := maxHigh.Load(); got != 1 {
+				t.Errorf("collectors of CostHigh running at the same time = %d, want 1", got)
+			}
+			if tt.err != nil {
+				return
+			}
+			for _, c := range collectors {
+				if data[c.RecordKey()] != c.RecordKey() {
+					t.Errorf("data of %s = %v, want %s", c.RecordKey(), data[c.RecordKey()], c.RecordKey())
+				}
+			}
+			if data["ts"] == nil {
+				t.Error("ts is not set")
+			}
+		})
+	}
+}